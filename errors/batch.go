@@ -0,0 +1,195 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CodeUnknown is the code of the errors which are neither CodeMsg nor gRPC status in a batch,
+// and the overall code of a batch with different codes.
+const CodeUnknown = -1
+
+type (
+	// ItemError is a failure of an item in a batch operation.
+	ItemError struct {
+		Index int
+		Err   error
+	}
+
+	// BatchError collects the indexed failures of a batch operation.
+	// It implements the error interface, and supports errors.Is and errors.As on the failures.
+	// It's not safe for concurrent use.
+	BatchError struct {
+		total int
+		items []ItemError
+		// positions maps the indexes to the positions in items.
+		positions map[int]int
+		sorted    bool
+		decider   func([]ItemError) int
+	}
+
+	// BatchOption represents an option for NewBatchError.
+	BatchOption func(*BatchError)
+)
+
+// WithCodeDecider is an option to set the function which decides the overall code of the failures.
+func WithCodeDecider(decider func([]ItemError) int) BatchOption {
+	return func(b *BatchError) {
+		b.decider = decider
+	}
+}
+
+// NewBatchError creates a BatchError for a batch operation with total items.
+// By default, the overall code is the code of the failures if they share the same code,
+// otherwise CodeUnknown.
+func NewBatchError(total int, opts ...BatchOption) *BatchError {
+	b := &BatchError{
+		total:     total,
+		positions: make(map[int]int),
+		sorted:    true,
+		decider:   defaultCodeDecider,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Code returns the code of err, CodeUnknown if err is neither CodeMsg nor gRPC status.
+func (e ItemError) Code() int {
+	if cm, ok := FromError(e.Err); ok {
+		return cm.Code
+	}
+
+	return CodeUnknown
+}
+
+// Add records err as the failure of the item at index, nil err is ignored.
+// A later failure of the same item replaces the earlier one.
+// It panics if index is out of the range of the batch.
+func (b *BatchError) Add(index int, err error) {
+	if index < 0 || index >= b.total {
+		panic(fmt.Sprintf("batch item index %d out of range [0, %d)", index, b.total))
+	}
+	if err == nil {
+		return
+	}
+
+	if pos, ok := b.positions[index]; ok {
+		b.items[pos].Err = err
+		return
+	}
+
+	b.positions[index] = len(b.items)
+	b.items = append(b.items, ItemError{Index: index, Err: err})
+	b.sorted = len(b.items) == 1 || b.sorted && b.items[len(b.items)-2].Index < index
+}
+
+// Code returns the overall code of the failures.
+func (b *BatchError) Code() int {
+	return b.decider(b.sortedItems())
+}
+
+// Errors returns the failures ordered by index.
+func (b *BatchError) Errors() []ItemError {
+	return append([]ItemError(nil), b.sortedItems()...)
+}
+
+// Total returns the number of items in the batch.
+func (b *BatchError) Total() int {
+	return b.total
+}
+
+// Failed returns the number of failed items.
+func (b *BatchError) Failed() int {
+	return len(b.items)
+}
+
+// PartialSuccess returns true if some items failed and the others succeeded.
+func (b *BatchError) PartialSuccess() bool {
+	return len(b.items) > 0 && len(b.items) < b.total
+}
+
+// Err returns b if any item failed, otherwise nil.
+func (b *BatchError) Err() error {
+	if len(b.items) == 0 {
+		return nil
+	}
+
+	return b
+}
+
+func (b *BatchError) Error() string {
+	msgs := make([]string, 0, len(b.items))
+	for _, item := range b.sortedItems() {
+		msgs = append(msgs, fmt.Sprintf("[%d] %s", item.Index, item.Err.Error()))
+	}
+
+	return fmt.Sprintf("%d of %d items failed: %s", len(b.items), b.total, strings.Join(msgs, "; "))
+}
+
+// Is reports whether any failure matches target, it's used by errors.Is.
+// It's implemented besides Unwrap since errors.Is honours Unwrap() []error from Go 1.20 only.
+func (b *BatchError) Is(target error) bool {
+	for _, item := range b.sortedItems() {
+		if errors.Is(item.Err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first failure matching target, it's used by errors.As.
+// It's implemented besides Unwrap since errors.As honours Unwrap() []error from Go 1.20 only.
+func (b *BatchError) As(target any) bool {
+	for _, item := range b.sortedItems() {
+		if errors.As(item.Err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unwrap returns the failures, it's used by errors.Is and errors.As from Go 1.20.
+func (b *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(b.items))
+	for _, item := range b.sortedItems() {
+		errs = append(errs, item.Err)
+	}
+	return errs
+}
+
+// sortedItems returns the failures ordered by index, they're sorted once after being added.
+func (b *BatchError) sortedItems() []ItemError {
+	if b.sorted {
+		return b.items
+	}
+
+	sort.Slice(b.items, func(i, j int) bool {
+		return b.items[i].Index < b.items[j].Index
+	})
+	for i, item := range b.items {
+		b.positions[item.Index] = i
+	}
+	b.sorted = true
+	return b.items
+}
+
+func defaultCodeDecider(items []ItemError) int {
+	if len(items) == 0 {
+		return 0
+	}
+
+	code := items[0].Code()
+	for _, item := range items[1:] {
+		if item.Code() != code {
+			return CodeUnknown
+		}
+	}
+
+	return code
+}
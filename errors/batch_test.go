@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatchError(t *testing.T) {
+	errNotFound := New(404, "not found")
+	b := NewBatchError(5)
+	assert.NoError(t, b.Err())
+	assert.Equal(t, 0, b.Code())
+	assert.False(t, b.PartialSuccess())
+
+	b.Add(3, errNotFound)
+	b.Add(2, nil)
+	b.Add(1, New(404, "not found"))
+	assert.Error(t, b.Err())
+	assert.Equal(t, 5, b.Total())
+	assert.Equal(t, 2, b.Failed())
+	assert.True(t, b.PartialSuccess())
+	assert.Equal(t, 404, b.Code())
+	assert.Equal(t, []int{1, 3}, []int{b.Errors()[0].Index, b.Errors()[1].Index})
+	assert.Equal(t, "2 of 5 items failed: [1] code: 404, msg: not found; [3] code: 404, msg: not found", b.Error())
+	assert.True(t, errors.Is(b, errNotFound))
+
+	var cm *CodeMsg
+	assert.True(t, errors.As(b, &cm))
+	// the methods work without the Unwrap() []error support of Go 1.20
+	assert.True(t, b.Is(errNotFound))
+	assert.False(t, b.Is(errors.New("test")))
+	cm = nil
+	assert.True(t, b.As(&cm))
+	assert.Equal(t, 404, cm.Code)
+	var se interface{ Timeout() bool }
+	assert.False(t, b.As(&se))
+
+	b.Add(0, errors.New("test"))
+	assert.Equal(t, CodeUnknown, b.Code())
+}
+
+func TestBatchErrorAllFailed(t *testing.T) {
+	b := NewBatchError(2, WithCodeDecider(func(items []ItemError) int {
+		return 1000 + len(items)
+	}))
+	b.Add(0, status.Error(codes.NotFound, "not found"))
+	b.Add(1, errors.New("test"))
+	assert.False(t, b.PartialSuccess())
+	assert.Equal(t, 1002, b.Code())
+	assert.Equal(t, int(codes.NotFound), b.Errors()[0].Code())
+	assert.Equal(t, CodeUnknown, b.Errors()[1].Code())
+	assert.Len(t, b.Unwrap(), 2)
+}
+
+func TestBatchErrorAdd(t *testing.T) {
+	b := NewBatchError(3)
+	b.Add(2, New(404, "not found"))
+	b.Add(0, errors.New("test"))
+	b.Add(2, New(409, "conflict"))
+	assert.Equal(t, 2, b.Failed())
+	assert.Equal(t, "2 of 3 items failed: [0] test; [2] code: 409, msg: conflict", b.Error())
+	b.Add(1, New(500, "internal"))
+	assert.Equal(t, []int{0, 1, 2}, []int{b.Errors()[0].Index, b.Errors()[1].Index, b.Errors()[2].Index})
+	b.Add(0, New(400, "bad request"))
+	assert.Equal(t, 400, b.Errors()[0].Code())
+	assert.Equal(t, 3, b.Failed())
+
+	assert.Panics(t, func() {
+		b.Add(-1, errors.New("test"))
+	})
+	assert.Panics(t, func() {
+		b.Add(3, errors.New("test"))
+	})
+}
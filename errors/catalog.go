@@ -0,0 +1,347 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// KindInvalidParams represents the requests with invalid parameters.
+	KindInvalidParams Kind = iota + 1
+	// KindUnauthorized represents the requests without valid credentials.
+	KindUnauthorized
+	// KindForbidden represents the requests without permission.
+	KindForbidden
+	// KindNotFound represents the requests for absent resources.
+	KindNotFound
+	// KindConflict represents the requests conflicting with the current state of resources.
+	KindConflict
+	// KindRateLimited represents the requests rejected by rate limiting, they're retryable.
+	KindRateLimited
+	// KindInternal represents the unexpected server failures, their messages are unsafe to expose.
+	KindInternal
+	// KindUnavailable represents the temporary unavailability of the server or its dependencies,
+	// they're retryable.
+	KindUnavailable
+	// KindPayloadTooLarge represents the requests exceeding the size limits, like the uploaded files.
+	KindPayloadTooLarge
+	// KindUnsupportedMediaType represents the requests with unsupported content types.
+	KindUnsupportedMediaType
+)
+
+var (
+	kindNames = map[Kind]string{
+		KindInvalidParams: "InvalidParams",
+		KindUnauthorized:  "Unauthorized",
+		KindForbidden:     "Forbidden",
+		KindNotFound:      "NotFound",
+		KindConflict:      "Conflict",
+		KindRateLimited:   "RateLimited",
+		KindInternal:      "Internal",
+		KindUnavailable:   "Unavailable",
+
+		KindPayloadTooLarge:      "PayloadTooLarge",
+		KindUnsupportedMediaType: "UnsupportedMediaType",
+	}
+
+	defaultCatalog = map[Kind]Entry{
+		KindInvalidParams: {Code: 40000, Msg: "invalid params", HTTPStatus: http.StatusBadRequest,
+			GRPCCode: codes.InvalidArgument, Category: CategoryClient},
+		KindUnauthorized: {Code: 40100, Msg: "unauthorized", HTTPStatus: http.StatusUnauthorized,
+			GRPCCode: codes.Unauthenticated, Category: CategoryClient},
+		KindForbidden: {Code: 40300, Msg: "forbidden", HTTPStatus: http.StatusForbidden,
+			GRPCCode: codes.PermissionDenied, Category: CategoryClient},
+		KindNotFound: {Code: 40400, Msg: "not found", HTTPStatus: http.StatusNotFound,
+			GRPCCode: codes.NotFound, Category: CategoryClient},
+		KindConflict: {Code: 40900, Msg: "conflict", HTTPStatus: http.StatusConflict,
+			GRPCCode: codes.AlreadyExists, Category: CategoryClient},
+		KindPayloadTooLarge: {Code: 41300, Msg: "payload too large", HTTPStatus: http.StatusRequestEntityTooLarge,
+			GRPCCode: codes.InvalidArgument, Category: CategoryClient},
+		KindUnsupportedMediaType: {Code: 41500, Msg: "unsupported media type",
+			HTTPStatus: http.StatusUnsupportedMediaType, GRPCCode: codes.InvalidArgument, Category: CategoryClient},
+		KindRateLimited: {Code: 42900, Msg: "too many requests", HTTPStatus: http.StatusTooManyRequests,
+			GRPCCode: codes.ResourceExhausted, Category: CategoryClient, Retryable: true},
+		KindInternal: {Code: 50000, Msg: "internal error", HTTPStatus: http.StatusInternalServerError,
+			GRPCCode: codes.Internal, Category: CategoryServer, Severity: SeverityError},
+		KindUnavailable: {Code: 50300, Msg: "service unavailable", HTTPStatus: http.StatusServiceUnavailable,
+			GRPCCode: codes.Unavailable, Category: CategoryDependency, Severity: SeverityWarning, Retryable: true},
+	}
+
+	catalog = copyCatalog(defaultCatalog)
+	// codeIndex maps the codes into the kinds of the catalog, it's rebuilt on changing the catalog.
+	codeIndex   = indexCodes(catalog)
+	extensions  = make(map[int]Entry)
+	catalogLock sync.RWMutex
+)
+
+type (
+	// Kind identifies a standard API failure in the catalog.
+	Kind int
+
+	// Entry describes an error in the catalog, the zero fields of a configured Entry
+	// keep the default values of its Kind.
+	Entry struct {
+		Code       int        `json:",optional"`
+		Msg        string     `json:",optional"`
+		HTTPStatus int        `json:",optional"`
+		GRPCCode   codes.Code `json:",optional"`
+		Category   Category   `json:",optional"`
+		Severity   Severity   `json:",optional"`
+		Retryable  bool       `json:",optional"`
+		// RetryAfter represents the suggested delay before retrying, it's only used if Retryable is true.
+		RetryAfter time.Duration `json:",optional"`
+	}
+)
+
+// Configure overrides the catalog entries of the kinds in entries, it's usually used to renumber the codes
+// with the entries loaded from the configuration, for example:
+//
+//	{"NotFound": {"Code": 1404, "Msg": "resource not found"}}
+//
+// If several kinds share a code, the code is resolved into the smallest kind by LookupCode and HTTPStatus.
+func Configure(entries map[Kind]Entry) {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	for kind, entry := range entries {
+		catalog[kind] = mergeEntry(catalog[kind], entry)
+	}
+	codeIndex = indexCodes(catalog)
+}
+
+// ResetCatalog restores the default catalog and removes the registered entries.
+func ResetCatalog() {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	catalog = copyCatalog(defaultCatalog)
+	codeIndex = indexCodes(catalog)
+	extensions = make(map[int]Entry)
+}
+
+// Register extends the catalog with a custom entry, so that its code is mapped into
+// the http status and the gRPC code by HTTPStatus and CodeMsg.GRPCStatus.
+// The kinds in the catalog take precedence over the registered entries with the same codes.
+func Register(entry Entry) {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	extensions[entry.Code] = entry
+}
+
+// Lookup returns the catalog entry of kind.
+func Lookup(kind Kind) (Entry, bool) {
+	catalogLock.RLock()
+	defer catalogLock.RUnlock()
+	entry, ok := catalog[kind]
+	return entry, ok
+}
+
+// LookupCode returns the catalog entry with the business code, including the registered ones.
+func LookupCode(code int) (Entry, bool) {
+	catalogLock.RLock()
+	defer catalogLock.RUnlock()
+	if kind, ok := codeIndex[code]; ok {
+		return catalog[kind], true
+	}
+
+	entry, ok := extensions[code]
+	return entry, ok
+}
+
+// Entries returns the entries of the catalog and the registered ones, ordered by code.
+func Entries() []Entry {
+	catalogLock.RLock()
+	defer catalogLock.RUnlock()
+
+	entries := make([]Entry, 0, len(catalog)+len(extensions))
+	for _, entry := range catalog {
+		entries = append(entries, entry)
+	}
+	for _, entry := range extensions {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+
+	return entries
+}
+
+// HTTPStatus returns the http status of err by its business code in the catalog,
+// it returns false if err is not a CodeMsg or its code is not in the catalog.
+func HTTPStatus(err error) (int, bool) {
+	cm, ok := FromError(err)
+	if !ok {
+		return 0, false
+	}
+
+	entry, ok := LookupCode(cm.Code)
+	if !ok || entry.HTTPStatus == 0 {
+		return 0, false
+	}
+
+	return entry.HTTPStatus, true
+}
+
+// NewKind creates a CodeMsg of kind, the default message of kind is used if msg is empty.
+func NewKind(kind Kind, msg string, opts ...Option) error {
+	return newKind(1, kind, msg, opts...)
+}
+
+// InvalidParams creates a CodeMsg of KindInvalidParams, the default message is used if msg is empty.
+func InvalidParams(msg string, opts ...Option) error {
+	return newKind(1, KindInvalidParams, msg, opts...)
+}
+
+// Unauthorized creates a CodeMsg of KindUnauthorized, the default message is used if msg is empty.
+func Unauthorized(msg string, opts ...Option) error {
+	return newKind(1, KindUnauthorized, msg, opts...)
+}
+
+// Forbidden creates a CodeMsg of KindForbidden, the default message is used if msg is empty.
+func Forbidden(msg string, opts ...Option) error {
+	return newKind(1, KindForbidden, msg, opts...)
+}
+
+// NotFound creates a CodeMsg of KindNotFound, the default message is used if msg is empty.
+func NotFound(msg string, opts ...Option) error {
+	return newKind(1, KindNotFound, msg, opts...)
+}
+
+// Conflict creates a CodeMsg of KindConflict, the default message is used if msg is empty.
+func Conflict(msg string, opts ...Option) error {
+	return newKind(1, KindConflict, msg, opts...)
+}
+
+// PayloadTooLarge creates a CodeMsg of KindPayloadTooLarge, the default message is used if msg is empty.
+func PayloadTooLarge(msg string, opts ...Option) error {
+	return newKind(1, KindPayloadTooLarge, msg, opts...)
+}
+
+// UnsupportedMediaType creates a CodeMsg of KindUnsupportedMediaType,
+// the default message is used if msg is empty.
+func UnsupportedMediaType(msg string, opts ...Option) error {
+	return newKind(1, KindUnsupportedMediaType, msg, opts...)
+}
+
+// RateLimited creates a CodeMsg of KindRateLimited, the default message is used if msg is empty.
+func RateLimited(msg string, opts ...Option) error {
+	return newKind(1, KindRateLimited, msg, opts...)
+}
+
+// Internal creates a CodeMsg of KindInternal, the default message is used if msg is empty.
+func Internal(msg string, opts ...Option) error {
+	return newKind(1, KindInternal, msg, opts...)
+}
+
+// Unavailable creates a CodeMsg of KindUnavailable, the default message is used if msg is empty.
+func Unavailable(msg string, opts ...Option) error {
+	return newKind(1, KindUnavailable, msg, opts...)
+}
+
+// IsKind reports whether err is a CodeMsg of kind.
+func IsKind(err error, kind Kind) bool {
+	cm, ok := FromError(err)
+	if !ok {
+		return false
+	}
+
+	entry, ok := Lookup(kind)
+	return ok && entry.Code == cm.Code
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, it's used to load the catalog from the configuration.
+func (k *Kind) UnmarshalText(text []byte) error {
+	for kind, name := range kindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown error kind: %s", text)
+}
+
+// newKind creates a CodeMsg of kind, skip is the number of frames to skip on capturing the stack,
+// 0 identifies the caller of newKind.
+func newKind(skip int, kind Kind, msg string, opts ...Option) error {
+	entry, ok := Lookup(kind)
+	if !ok {
+		entry, _ = Lookup(KindInternal)
+	}
+	if len(msg) == 0 {
+		msg = entry.Msg
+	}
+
+	options := []Option{WithCategory(entry.Category), WithSeverity(entry.Severity)}
+	if entry.Retryable {
+		options = append(options, WithRetryable(entry.RetryAfter))
+	}
+
+	return newCodeMsg(skip+1, entry.Code, msg, append(options, opts...)...)
+}
+
+func mergeEntry(base, override Entry) Entry {
+	if override.Code != 0 {
+		base.Code = override.Code
+	}
+	if len(override.Msg) > 0 {
+		base.Msg = override.Msg
+	}
+	if override.HTTPStatus != 0 {
+		base.HTTPStatus = override.HTTPStatus
+	}
+	if override.GRPCCode != codes.OK {
+		base.GRPCCode = override.GRPCCode
+	}
+	if override.Category != CategoryUnknown {
+		base.Category = override.Category
+	}
+	if override.Severity != SeverityUnspecified {
+		base.Severity = override.Severity
+	}
+	if override.Retryable {
+		base.Retryable = true
+	}
+	if override.RetryAfter > 0 {
+		base.RetryAfter = override.RetryAfter
+	}
+
+	return base
+}
+
+// indexCodes maps the codes of c into their kinds, the smallest kind wins if several kinds share a code,
+// so that the lookups are deterministic.
+func indexCodes(c map[Kind]Entry) map[int]Kind {
+	index := make(map[int]Kind, len(c))
+	for kind, entry := range c {
+		if existing, ok := index[entry.Code]; !ok || kind < existing {
+			index[entry.Code] = kind
+		}
+	}
+	return index
+}
+
+func copyCatalog(src map[Kind]Entry) map[Kind]Entry {
+	dst := make(map[Kind]Entry, len(src))
+	for kind, entry := range src {
+		dst[kind] = entry
+	}
+	return dst
+}
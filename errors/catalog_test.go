@@ -0,0 +1,143 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestCatalogConstructors(t *testing.T) {
+	tests := []struct {
+		fn       func(string, ...Option) error
+		kind     Kind
+		code     int
+		status   int
+		grpcCode codes.Code
+	}{
+		{InvalidParams, KindInvalidParams, 40000, http.StatusBadRequest, codes.InvalidArgument},
+		{Unauthorized, KindUnauthorized, 40100, http.StatusUnauthorized, codes.Unauthenticated},
+		{Forbidden, KindForbidden, 40300, http.StatusForbidden, codes.PermissionDenied},
+		{NotFound, KindNotFound, 40400, http.StatusNotFound, codes.NotFound},
+		{Conflict, KindConflict, 40900, http.StatusConflict, codes.AlreadyExists},
+		{PayloadTooLarge, KindPayloadTooLarge, 41300, http.StatusRequestEntityTooLarge, codes.InvalidArgument},
+		{UnsupportedMediaType, KindUnsupportedMediaType, 41500, http.StatusUnsupportedMediaType,
+			codes.InvalidArgument},
+		{RateLimited, KindRateLimited, 42900, http.StatusTooManyRequests, codes.ResourceExhausted},
+		{Internal, KindInternal, 50000, http.StatusInternalServerError, codes.Internal},
+		{Unavailable, KindUnavailable, 50300, http.StatusServiceUnavailable, codes.Unavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.kind.String(), func(t *testing.T) {
+			err := test.fn("")
+			cm, ok := FromError(err)
+			assert.True(t, ok)
+			assert.Equal(t, test.code, cm.Code)
+			assert.NotEmpty(t, cm.Msg)
+			assert.True(t, IsKind(err, test.kind))
+
+			status, ok := HTTPStatus(err)
+			assert.True(t, ok)
+			assert.Equal(t, test.status, status)
+			assert.Equal(t, test.grpcCode, cm.GRPCStatus().Code())
+		})
+	}
+}
+
+func TestCatalogOptions(t *testing.T) {
+	err := NotFound("user not found", WithSeverity(SeverityDebug))
+	cm, _ := FromError(err)
+	assert.Equal(t, "user not found", cm.Msg)
+	assert.Equal(t, CategoryClient, cm.Category())
+	assert.Equal(t, SeverityDebug, cm.Severity())
+	assert.Contains(t, cm.Caller(), "catalog_test.go")
+
+	cm, _ = FromError(Unavailable(""))
+	assert.True(t, cm.Retryable())
+	assert.False(t, cm.Safe())
+
+	cm, _ = FromError(NewKind(Kind(100), ""))
+	assert.Equal(t, 50000, cm.Code)
+}
+
+func TestConfigure(t *testing.T) {
+	defer ResetCatalog()
+
+	var entries map[Kind]Entry
+	assert.NoError(t, json.Unmarshal([]byte(`{"NotFound": {"Code": 1404, "Msg": "resource not found"}}`),
+		&entries))
+	Configure(entries)
+
+	err := NotFound("")
+	assert.Equal(t, &CodeMsg{Code: 1404, Msg: "resource not found"}, &CodeMsg{
+		Code: err.(*CodeMsg).Code,
+		Msg:  err.(*CodeMsg).Msg,
+	})
+	status, ok := HTTPStatus(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, codes.NotFound, err.(*CodeMsg).GRPCStatus().Code())
+
+	_, ok = HTTPStatus(New(40400, "old code"))
+	assert.False(t, ok)
+}
+
+func TestConfigureDuplicateCodes(t *testing.T) {
+	defer ResetCatalog()
+
+	Configure(map[Kind]Entry{
+		KindNotFound:  {Code: 1000},
+		KindForbidden: {Code: 1000},
+		KindConflict:  {Code: 1000},
+	})
+	for i := 0; i < 10; i++ {
+		entry, ok := LookupCode(1000)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusForbidden, entry.HTTPStatus)
+	}
+
+	_, ok := LookupCode(40300)
+	assert.False(t, ok)
+	Register(Entry{Code: 1000, HTTPStatus: http.StatusTeapot})
+	entry, _ := LookupCode(1000)
+	assert.Equal(t, http.StatusForbidden, entry.HTTPStatus)
+}
+
+func TestRegister(t *testing.T) {
+	defer ResetCatalog()
+
+	Register(Entry{Code: 2001, HTTPStatus: http.StatusPaymentRequired, GRPCCode: codes.FailedPrecondition})
+	err := fmt.Errorf("wrapped: %w", New(2001, "payment required"))
+	status, ok := HTTPStatus(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusPaymentRequired, status)
+	assert.Equal(t, codes.FailedPrecondition, (&CodeMsg{Code: 2001}).GRPCStatus().Code())
+
+	entries := Entries()
+	assert.Len(t, entries, 11)
+	assert.Equal(t, 2001, entries[0].Code)
+	assert.Equal(t, 50300, entries[10].Code)
+
+	_, ok = HTTPStatus(New(2002, "unknown"))
+	assert.False(t, ok)
+	_, ok = HTTPStatus(errors.New("test"))
+	assert.False(t, ok)
+	assert.False(t, IsKind(errors.New("test"), KindNotFound))
+}
+
+func TestKindText(t *testing.T) {
+	var kind Kind
+	assert.NoError(t, kind.UnmarshalText([]byte("RateLimited")))
+	assert.Equal(t, KindRateLimited, kind)
+	assert.Error(t, kind.UnmarshalText([]byte("Teapot")))
+	assert.Equal(t, "Kind(100)", Kind(100).String())
+
+	text, err := KindConflict.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "Conflict", string(text))
+}
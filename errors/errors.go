@@ -1,19 +1,62 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
 
 // CodeMsg is a struct that contains a code and a message.
 // It implements the error interface.
 type CodeMsg struct {
 	Code int
 	Msg  string
+
+	category   Category
+	severity   Severity
+	retryable  bool
+	retryAfter time.Duration
+	safe       *bool
+	// grpcCode is the code of the status without the business code, which is converted by FromStatus,
+	// it's kept to convert the CodeMsg back into the same status.
+	grpcCode codes.Code
+	// extra holds the non-comparable fields behind a pointer, so that CodeMsg stays comparable.
+	extra *extra
+}
+
+// extra holds the fields of CodeMsg which are not comparable.
+type extra struct {
+	details any
+	stack   []uintptr
 }
 
 func (c *CodeMsg) Error() string {
 	return fmt.Sprintf("code: %d, msg: %s", c.Code, c.Msg)
 }
 
-// New creates a new CodeMsg.
-func New(code int, msg string) error {
-	return &CodeMsg{Code: code, Msg: msg}
+// New creates a new CodeMsg, the stack is captured according to the mode set by SetStackMode.
+func New(code int, msg string, opts ...Option) error {
+	return newCodeMsg(1, code, msg, opts...)
+}
+
+// newCodeMsg creates a new CodeMsg, skip is the number of frames to skip on capturing the stack,
+// 0 identifies the caller of newCodeMsg.
+func newCodeMsg(skip, code int, msg string, opts ...Option) *CodeMsg {
+	cm := &CodeMsg{Code: code, Msg: msg}
+	if stack := callers(skip + 1); len(stack) > 0 {
+		cm.extras().stack = stack
+	}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	return cm
+}
+
+// extras returns the extra of c, it's allocated on the first use.
+func (c *CodeMsg) extras() *extra {
+	if c.extra == nil {
+		c.extra = &extra{}
+	}
+	return c.extra
 }
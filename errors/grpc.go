@@ -0,0 +1,177 @@
+package errors
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// ErrorDomain is the domain of the errdetails.ErrorInfo detail which carries the business code.
+	ErrorDomain = "x.zeromicro"
+	// ErrorReason is the reason of the errdetails.ErrorInfo detail which carries the business code.
+	ErrorReason = "BUSINESS_ERROR"
+
+	metadataCode      = "code"
+	metadataCategory  = "category"
+	metadataSeverity  = "severity"
+	metadataRetryable = "retryable"
+	metadataSafe      = "safe"
+)
+
+var (
+	grpcCodeMapper = defaultGRPCCodeMapper
+	mapperLock     sync.RWMutex
+)
+
+// SetGRPCCodeMapper sets the function which maps a business code into a gRPC code,
+// it's used by CodeMsg.GRPCStatus. By default, the codes in the catalog are mapped into
+// the gRPC codes of their entries, and the others are mapped into codes.Unknown.
+func SetGRPCCodeMapper(mapper func(code int) codes.Code) {
+	mapperLock.Lock()
+	defer mapperLock.Unlock()
+	if mapper == nil {
+		mapper = defaultGRPCCodeMapper
+	}
+	grpcCodeMapper = mapper
+}
+
+// GRPCStatus converts c into a gRPC status, the gRPC code is mapped from the business code,
+// the business code and the metadata are carried by an errdetails.ErrorInfo detail,
+// and the retry delay is carried by an errdetails.RetryInfo detail, so that they can be restored by FromStatus.
+// A CodeMsg converted by FromStatus from a status without the business code is converted back
+// into a status with the original gRPC code.
+func (c *CodeMsg) GRPCStatus() *status.Status {
+	if c.grpcCode != codes.OK {
+		return status.New(c.grpcCode, c.Msg)
+	}
+
+	mapperLock.RLock()
+	mapper := grpcCodeMapper
+	mapperLock.RUnlock()
+
+	code := mapper(c.Code)
+	// a status with codes.OK is not an error, it can't carry the business code.
+	if code == codes.OK {
+		code = codes.Unknown
+	}
+
+	md := map[string]string{
+		metadataCode:      strconv.Itoa(c.Code),
+		metadataCategory:  strconv.Itoa(int(c.category)),
+		metadataSeverity:  strconv.Itoa(int(c.severity)),
+		metadataRetryable: strconv.FormatBool(c.retryable),
+	}
+	if c.safe != nil {
+		md[metadataSafe] = strconv.FormatBool(*c.safe)
+	}
+
+	st := status.New(code, c.Msg)
+	details := []protoiface.MessageV1{&errdetails.ErrorInfo{
+		Reason:   ErrorReason,
+		Domain:   ErrorDomain,
+		Metadata: md,
+	}}
+	if c.retryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(c.retryAfter),
+		})
+	}
+
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+
+	return detailed
+}
+
+// FromStatus converts s into a CodeMsg, the business code is restored from the errdetails.ErrorInfo detail
+// added by CodeMsg.GRPCStatus, otherwise the gRPC code is used as the business code,
+// and it's kept to convert the CodeMsg back into a status with the same gRPC code.
+func FromStatus(s *status.Status) *CodeMsg {
+	var cm *CodeMsg
+	var retryAfter time.Duration
+	for _, detail := range s.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() == ErrorDomain && cm == nil {
+				cm = fromErrorInfo(d, s.Message())
+			}
+		case *errdetails.RetryInfo:
+			retryAfter = d.GetRetryDelay().AsDuration()
+		}
+	}
+
+	if cm == nil {
+		return &CodeMsg{Code: int(s.Code()), Msg: s.Message(), grpcCode: s.Code()}
+	}
+
+	cm.retryAfter = retryAfter
+	return cm
+}
+
+// HasBusinessCode reports whether s carries the business code added by CodeMsg.GRPCStatus.
+func HasBusinessCode(s *status.Status) bool {
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FromError converts err into a CodeMsg, it returns false if err is neither a CodeMsg nor a gRPC status error.
+func FromError(err error) (*CodeMsg, bool) {
+	var cm *CodeMsg
+	if errors.As(err, &cm) {
+		return cm, true
+	}
+
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return FromStatus(se.GRPCStatus()), true
+	}
+
+	return nil, false
+}
+
+func fromErrorInfo(info *errdetails.ErrorInfo, msg string) *CodeMsg {
+	md := info.GetMetadata()
+	code, err := strconv.Atoi(md[metadataCode])
+	if err != nil {
+		return nil
+	}
+
+	cm := &CodeMsg{Code: code, Msg: msg}
+	if category, err := strconv.Atoi(md[metadataCategory]); err == nil {
+		cm.category = Category(category)
+	}
+	if severity, err := strconv.Atoi(md[metadataSeverity]); err == nil {
+		cm.severity = Severity(severity)
+	}
+	if retryable, err := strconv.ParseBool(md[metadataRetryable]); err == nil {
+		cm.retryable = retryable
+	}
+	if safe, err := strconv.ParseBool(md[metadataSafe]); err == nil {
+		cm.safe = &safe
+	}
+
+	return cm
+}
+
+func defaultGRPCCodeMapper(code int) codes.Code {
+	if entry, ok := LookupCode(code); ok && entry.GRPCCode != codes.OK {
+		return entry.GRPCCode
+	}
+
+	return codes.Unknown
+}
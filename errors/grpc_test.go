@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCodeMsg_GRPCStatus(t *testing.T) {
+	cm := &CodeMsg{Code: 1001, Msg: "test"}
+	st := cm.GRPCStatus()
+	assert.Equal(t, codes.Unknown, st.Code())
+	assert.Equal(t, "test", st.Message())
+	assert.Len(t, st.Details(), 1)
+
+	st, ok := status.FromError(New(1001, "test"))
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unknown, st.Code())
+}
+
+func TestSetGRPCCodeMapper(t *testing.T) {
+	SetGRPCCodeMapper(func(code int) codes.Code {
+		if code == 404 {
+			return codes.NotFound
+		}
+		return codes.OK
+	})
+	defer SetGRPCCodeMapper(nil)
+
+	assert.Equal(t, codes.NotFound, (&CodeMsg{Code: 404}).GRPCStatus().Code())
+	assert.Equal(t, codes.Unknown, (&CodeMsg{Code: 1}).GRPCStatus().Code())
+}
+
+func TestFromStatus(t *testing.T) {
+	// round trip
+	st := status.Convert((&CodeMsg{Code: 5, Msg: "test"}).GRPCStatus().Err())
+	assert.Equal(t, &CodeMsg{Code: 5, Msg: "test"}, FromStatus(st))
+
+	// plain status
+	assert.Equal(t, &CodeMsg{Code: int(codes.NotFound), Msg: "not found", grpcCode: codes.NotFound},
+		FromStatus(status.New(codes.NotFound, "not found")))
+}
+
+func TestHasBusinessCode(t *testing.T) {
+	assert.True(t, HasBusinessCode((&CodeMsg{Code: 1001, Msg: "test"}).GRPCStatus()))
+	assert.False(t, HasBusinessCode(status.New(codes.Unavailable, "connection refused")))
+}
+
+func TestFromStatusRoundTrip(t *testing.T) {
+	cm := FromStatus(status.New(codes.Unavailable, "connection refused"))
+	assert.Equal(t, int(codes.Unavailable), cm.Code)
+	st := cm.GRPCStatus()
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "connection refused", st.Message())
+	assert.Empty(t, st.Details())
+	assert.Equal(t, codes.Unavailable, status.Code(cm))
+	assert.Equal(t, cm, FromStatus(st))
+}
+
+func TestFromError(t *testing.T) {
+	SetStackMode(StackOff)
+	defer SetStackMode(StackCaller)
+
+	cm, ok := FromError(fmt.Errorf("wrapped: %w", New(1, "test")))
+	assert.True(t, ok)
+	assert.Equal(t, &CodeMsg{Code: 1, Msg: "test"}, cm)
+
+	cm, ok = FromError(fmt.Errorf("wrapped: %w", status.Error(codes.NotFound, "not found")))
+	assert.True(t, ok)
+	assert.Equal(t, &CodeMsg{Code: int(codes.NotFound), Msg: "not found", grpcCode: codes.NotFound}, cm)
+
+	_, ok = FromError(errors.New("test"))
+	assert.False(t, ok)
+}
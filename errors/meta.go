@@ -0,0 +1,149 @@
+package errors
+
+import "time"
+
+const (
+	// CategoryUnknown represents an unclassified error.
+	CategoryUnknown Category = iota
+	// CategoryClient represents an error caused by the client, like invalid parameters.
+	CategoryClient
+	// CategoryServer represents an error caused by the server itself.
+	CategoryServer
+	// CategoryDependency represents an error caused by a dependency, like a database or another service.
+	CategoryDependency
+)
+
+const (
+	// SeverityUnspecified represents an error without severity, it's not logged on responding,
+	// unless it's a CategoryServer or CategoryDependency error, or not safe, which is logged at error level.
+	SeverityUnspecified Severity = iota
+	// SeverityDebug represents an error logged at debug level.
+	SeverityDebug
+	// SeverityInfo represents an error logged at info level.
+	SeverityInfo
+	// SeverityWarning represents an error which deserves attention, it's logged at info level.
+	SeverityWarning
+	// SeverityError represents an error logged at error level.
+	SeverityError
+	// SeverityCritical represents an error which needs immediate action, it's logged at error level.
+	SeverityCritical
+)
+
+type (
+	// Category represents who is responsible for an error.
+	Category int
+
+	// Severity represents how serious an error is.
+	Severity int
+
+	// Option represents an option for New.
+	Option func(*CodeMsg)
+)
+
+// WithCategory is an option to set the category of the error.
+func WithCategory(category Category) Option {
+	return func(c *CodeMsg) {
+		c.category = category
+	}
+}
+
+// WithSeverity is an option to set the severity of the error.
+func WithSeverity(severity Severity) Option {
+	return func(c *CodeMsg) {
+		c.severity = severity
+	}
+}
+
+// WithRetryable is an option to mark the error as retryable, after is the suggested delay before retrying,
+// zero means no suggestion.
+func WithRetryable(after time.Duration) Option {
+	return func(c *CodeMsg) {
+		c.retryable = true
+		c.retryAfter = after
+	}
+}
+
+// WithSafe is an option to set whether the message is safe to expose to external callers.
+func WithSafe(safe bool) Option {
+	return func(c *CodeMsg) {
+		c.safe = &safe
+	}
+}
+
+// WithDetails is an option to attach the details to the error, like the field-level validation errors,
+// the details are rendered as the data of the base response if the message is safe to expose.
+func WithDetails(details any) Option {
+	return func(c *CodeMsg) {
+		c.extras().details = details
+	}
+}
+
+// Category returns the category of c.
+func (c *CodeMsg) Category() Category {
+	return c.category
+}
+
+// Severity returns the severity of c.
+func (c *CodeMsg) Severity() Severity {
+	return c.severity
+}
+
+// Retryable returns whether the failed operation can be retried.
+func (c *CodeMsg) Retryable() bool {
+	return c.retryable
+}
+
+// RetryAfter returns the suggested delay before retrying, zero means no suggestion.
+func (c *CodeMsg) RetryAfter() time.Duration {
+	return c.retryAfter
+}
+
+// Safe returns whether the message is safe to expose to external callers.
+// If it's not set by WithSafe, the messages of CategoryServer and CategoryDependency errors are unsafe,
+// and the others are safe.
+func (c *CodeMsg) Safe() bool {
+	if c.safe != nil {
+		return *c.safe
+	}
+
+	return c.category != CategoryServer && c.category != CategoryDependency
+}
+
+// Details returns the details attached by WithDetails.
+func (c *CodeMsg) Details() any {
+	if c.extra == nil {
+		return nil
+	}
+
+	return c.extra.details
+}
+
+func (c Category) String() string {
+	switch c {
+	case CategoryClient:
+		return "client"
+	case CategoryServer:
+		return "server"
+	case CategoryDependency:
+		return "dependency"
+	default:
+		return "unknown"
+	}
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unspecified"
+	}
+}
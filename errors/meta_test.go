@@ -0,0 +1,57 @@
+package errors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	cm := New(1, "test", WithCategory(CategoryDependency), WithSeverity(SeverityWarning),
+		WithRetryable(time.Second)).(*CodeMsg)
+	assert.Equal(t, CategoryDependency, cm.Category())
+	assert.Equal(t, SeverityWarning, cm.Severity())
+	assert.True(t, cm.Retryable())
+	assert.Equal(t, time.Second, cm.RetryAfter())
+	assert.False(t, cm.Safe())
+
+	cm = New(1, "test", WithCategory(CategoryServer), WithSafe(true)).(*CodeMsg)
+	assert.True(t, cm.Safe())
+
+	cm = New(1, "test").(*CodeMsg)
+	assert.Equal(t, CategoryUnknown, cm.Category())
+	assert.Equal(t, SeverityUnspecified, cm.Severity())
+	assert.False(t, cm.Retryable())
+	assert.True(t, cm.Safe())
+	assert.Nil(t, cm.Details())
+
+	cm = New(1, "test", WithDetails([]string{"detail"})).(*CodeMsg)
+	assert.Equal(t, []string{"detail"}, cm.Details())
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	cm := New(1, "test", WithCategory(CategoryDependency), WithSeverity(SeverityError),
+		WithRetryable(2*time.Second), WithSafe(true)).(*CodeMsg)
+	restored := FromStatus(status.Convert(cm.GRPCStatus().Err()))
+	// the stack is not carried across the process boundary.
+	cm.extra = nil
+	assert.Equal(t, cm, restored)
+}
+
+func TestCategory_String(t *testing.T) {
+	assert.Equal(t, "unknown", CategoryUnknown.String())
+	assert.Equal(t, "client", CategoryClient.String())
+	assert.Equal(t, "server", CategoryServer.String())
+	assert.Equal(t, "dependency", CategoryDependency.String())
+}
+
+func TestSeverity_String(t *testing.T) {
+	assert.Equal(t, "unspecified", SeverityUnspecified.String())
+	assert.Equal(t, "debug", SeverityDebug.String())
+	assert.Equal(t, "info", SeverityInfo.String())
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, "error", SeverityError.String())
+	assert.Equal(t, "critical", SeverityCritical.String())
+}
@@ -0,0 +1,111 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// StackOff disables the stack capture.
+	StackOff StackMode = iota
+	// StackCaller captures the caller frame only, it's the default mode, and it's cheap enough for production.
+	StackCaller
+	// StackFull captures the full stack, it's recommended for development only.
+	StackFull
+)
+
+const maxStackDepth = 32
+
+var stackMode = int32(StackCaller)
+
+// StackMode represents how the stack is captured on creating a CodeMsg.
+type StackMode int32
+
+// SetStackMode sets the stack capture mode of New, defaults to StackCaller.
+// It's usually set to StackFull in development, for example:
+//
+//	if c.Mode == service.DevMode {
+//		errors.SetStackMode(errors.StackFull)
+//	}
+func SetStackMode(mode StackMode) {
+	atomic.StoreInt32(&stackMode, int32(mode))
+}
+
+// Format formats c, %+v prints the error with the captured stack, %q prints the quoted error,
+// and the other verbs print the error only.
+func (c *CodeMsg) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		_, _ = io.WriteString(s, c.Error())
+		if s.Flag('+') {
+			for _, frame := range c.frames() {
+				_, _ = fmt.Fprintf(s, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+			}
+		}
+	case 'q':
+		_, _ = io.WriteString(s, strconv.Quote(c.Error()))
+	default:
+		_, _ = io.WriteString(s, c.Error())
+	}
+}
+
+// Caller returns the location where c is created, like file.go:10, it's empty if the stack is not captured.
+func (c *CodeMsg) Caller() string {
+	frames := c.frames()
+	if len(frames) == 0 {
+		return ""
+	}
+
+	return frames[0].File + ":" + strconv.Itoa(frames[0].Line)
+}
+
+// StackTrace returns the captured stack, one frame per line, it's empty if the stack is not captured.
+func (c *CodeMsg) StackTrace() string {
+	frames := c.frames()
+	lines := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		lines = append(lines, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (c *CodeMsg) frames() []runtime.Frame {
+	if c.extra == nil || len(c.extra.stack) == 0 {
+		return nil
+	}
+
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(c.extra.stack)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+
+	return frames
+}
+
+// callers captures the stack with the current mode, skip is the number of frames to skip,
+// 0 identifies the caller of callers.
+func callers(skip int) []uintptr {
+	var depth int
+	switch StackMode(atomic.LoadInt32(&stackMode)) {
+	case StackCaller:
+		depth = 1
+	case StackFull:
+		depth = maxStackDepth
+	default:
+		return nil
+	}
+
+	pcs := make([]uintptr, depth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
@@ -0,0 +1,59 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeMsg_Format(t *testing.T) {
+	cm := New(1, "test").(*CodeMsg)
+	assert.Equal(t, "code: 1, msg: test", fmt.Sprintf("%v", cm))
+	assert.Equal(t, "code: 1, msg: test", fmt.Sprintf("%s", cm))
+	assert.Equal(t, `"code: 1, msg: test"`, fmt.Sprintf("%q", cm))
+	assert.Equal(t, "code: 1, msg: test", fmt.Sprintf("%d", cm))
+
+	detailed := fmt.Sprintf("%+v", cm)
+	assert.True(t, strings.HasPrefix(detailed, "code: 1, msg: test\n"))
+	assert.Contains(t, detailed, "errors.TestCodeMsg_Format")
+	assert.Contains(t, detailed, "stack_test.go")
+}
+
+func TestCodeMsg_Caller(t *testing.T) {
+	cm := New(1, "test").(*CodeMsg)
+	assert.Contains(t, cm.Caller(), "stack_test.go:")
+	assert.Contains(t, cm.StackTrace(), "errors.TestCodeMsg_Caller")
+
+	assert.Empty(t, (&CodeMsg{Code: 1}).Caller())
+	assert.Empty(t, (&CodeMsg{Code: 1}).StackTrace())
+}
+
+func TestSetStackMode(t *testing.T) {
+	defer SetStackMode(StackCaller)
+
+	cm := New(1, "test").(*CodeMsg)
+	assert.Len(t, cm.frames(), 1)
+	assert.Contains(t, cm.Caller(), "stack_test.go:")
+
+	SetStackMode(StackFull)
+	cm = New(1, "test").(*CodeMsg)
+	assert.Greater(t, len(cm.frames()), 1)
+	assert.Contains(t, cm.Caller(), "stack_test.go:")
+
+	SetStackMode(StackOff)
+	cm = New(1, "test").(*CodeMsg)
+	assert.Empty(t, cm.Caller())
+	assert.Equal(t, "code: 1, msg: test", fmt.Sprintf("%+v", cm))
+}
+
+func TestCodeMsg_Comparable(t *testing.T) {
+	a := CodeMsg{Code: 1, Msg: "test"}
+	b := CodeMsg{Code: 1, Msg: "test"}
+	assert.True(t, a == b)
+
+	set := map[CodeMsg]struct{}{a: {}}
+	_, ok := set[b]
+	assert.True(t, ok)
+}
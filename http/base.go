@@ -3,10 +3,15 @@ package http
 import (
 	"context"
 	"encoding/xml"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/rest/httpx"
 	"github.com/zeromicro/x/errors"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
@@ -19,6 +24,11 @@ type BaseResponse[T any] struct {
 	Msg string `json:"msg" xml:"msg"`
 	// Data represents the business data.
 	Data T `json:"data,omitempty" xml:"data,omitempty"`
+	// CorrelationID represents the ID to look up the logs of a redacted error.
+	CorrelationID string `json:"correlationId,omitempty" xml:"correlationId,omitempty"`
+	// Caller represents the location where the error is created, it's only set in development,
+	// see SetIncludeCaller.
+	Caller string `json:"caller,omitempty" xml:"caller,omitempty"`
 }
 
 type baseXmlResponse[T any] struct {
@@ -28,28 +38,89 @@ type baseXmlResponse[T any] struct {
 	BaseResponse[T]
 }
 
-// JsonBaseResponse writes v into w with http.StatusOK.
+// JsonBaseResponse writes v into w with http.StatusOK,
+// or http.StatusMultiStatus if v is a partially succeeded *errors.BatchError.
 func JsonBaseResponse(w http.ResponseWriter, v any) {
-	httpx.OkJson(w, wrapBaseResponse(v))
+	code, resp := prepareBaseResponse(context.Background(), w, v)
+	httpx.WriteJson(w, code, resp)
+	observeBaseResponse(w, code, resp.Code)
 }
 
-// JsonBaseResponseCtx writes v into w with http.StatusOK.
+// JsonBaseResponseCtx writes v into w with http.StatusOK,
+// or http.StatusMultiStatus if v is a partially succeeded *errors.BatchError.
 func JsonBaseResponseCtx(ctx context.Context, w http.ResponseWriter, v any) {
-	httpx.OkJsonCtx(ctx, w, wrapBaseResponse(v))
+	code, resp := prepareBaseResponse(ctx, w, v)
+	writeJsonEnvelope(ctx, w, code, resp)
+	observeBaseResponse(w, code, resp.Code)
 }
 
-// XmlBaseResponse writes v into w with http.StatusOK.
+// XmlBaseResponse writes v into w with http.StatusOK,
+// or http.StatusMultiStatus if v is a partially succeeded *errors.BatchError.
 func XmlBaseResponse(w http.ResponseWriter, v any) {
-	OkXml(w, wrapXmlBaseResponse(v))
+	code, resp := prepareBaseResponse(context.Background(), w, v)
+	WriteXml(w, code, wrapXmlResponse(resp))
+	observeBaseResponse(w, code, resp.Code)
 }
 
-// XmlBaseResponseCtx writes v into w with http.StatusOK.
+// XmlBaseResponseCtx writes v into w with http.StatusOK,
+// or http.StatusMultiStatus if v is a partially succeeded *errors.BatchError.
 func XmlBaseResponseCtx(ctx context.Context, w http.ResponseWriter, v any) {
-	OkXmlCtx(ctx, w, wrapXmlBaseResponse(v))
+	code, resp := prepareBaseResponse(ctx, w, v)
+	writeXmlEnvelope(ctx, w, code, resp)
+	observeBaseResponse(w, code, resp.Code)
+}
+
+// prepareBaseResponse wraps v into a BaseResponse and returns it with the http status code,
+// if v is an error with metadata, it also sets the Retry-After header on w if w is not nil,
+// and logs the error with its severity.
+// The unsafe messages are redacted, see SetProductionMode.
+// The business code is recorded if w wraps a ResponseWriter.
+func prepareBaseResponse(ctx context.Context, w http.ResponseWriter, v any) (int, BaseResponse[any]) {
+	status, resp := doPrepareBaseResponse(ctx, w, v)
+	recordCode(w, resp.Code)
+	return status, resp
+}
+
+func doPrepareBaseResponse(ctx context.Context, w http.ResponseWriter, v any) (int, BaseResponse[any]) {
+	if batch, ok := v.(*errors.BatchError); ok {
+		return wrapBatchResponse(ctx, batch)
+	}
+
+	if cm, ok := codeMsgOf(v); ok {
+		// the gRPC statuses without business codes may carry internal details, like the addresses of dependencies
+		if err, ok := plainStatusError(v); ok && shouldRedact(err) {
+			id := correlationID(ctx)
+			logRedactedError(ctx, err, id)
+			return http.StatusOK, redactError(cm.Code, id)
+		}
+
+		setRetryAfter(w, cm)
+		if !cm.Safe() {
+			id := correlationID(ctx)
+			logCodeMsg(ctx, cm, logx.Field(correlationIDKey, id))
+			return http.StatusOK, redactError(cm.Code, id)
+		}
+
+		logCodeMsg(ctx, cm)
+		if shouldIncludeCaller() {
+			resp := wrapBaseResponse(v)
+			resp.Caller = cm.Caller()
+			return http.StatusOK, resp
+		}
+	} else if err, ok := v.(error); ok && shouldRedact(err) {
+		id := correlationID(ctx)
+		logRedactedError(ctx, err, id)
+		return http.StatusOK, redactError(BusinessCodeError, id)
+	}
+
+	return http.StatusOK, wrapBaseResponse(v)
 }
 
 func wrapXmlBaseResponse(v any) baseXmlResponse[any] {
-	base := wrapBaseResponse(v)
+	return wrapXmlResponse(wrapBaseResponse(v))
+}
+
+func wrapXmlResponse(base BaseResponse[any]) baseXmlResponse[any] {
 	return baseXmlResponse[any]{
 		Version:      xmlVersion,
 		Encoding:     xmlEncoding,
@@ -59,19 +130,16 @@ func wrapXmlBaseResponse(v any) baseXmlResponse[any] {
 
 func wrapBaseResponse(v any) BaseResponse[any] {
 	var resp BaseResponse[any]
+	if cm, ok := codeMsgOf(v); ok {
+		resp.Code = cm.Code
+		resp.Msg = exposedMsg(cm)
+		if cm.Safe() {
+			resp.Data = cm.Details()
+		}
+		return resp
+	}
+
 	switch data := v.(type) {
-	case *errors.CodeMsg:
-		resp.Code = data.Code
-		resp.Msg = data.Msg
-	case errors.CodeMsg:
-		resp.Code = data.Code
-		resp.Msg = data.Msg
-	case *status.Status:
-		resp.Code = int(data.Code())
-		resp.Msg = data.Message()
-	case interface{ GRPCStatus() *status.Status }:
-		resp.Code = int(data.GRPCStatus().Code())
-		resp.Msg = data.GRPCStatus().Message()
 	case error:
 		resp.Code = BusinessCodeError
 		resp.Msg = data.Error()
@@ -83,3 +151,87 @@ func wrapBaseResponse(v any) BaseResponse[any] {
 
 	return resp
 }
+
+func codeMsgOf(v any) (*errors.CodeMsg, bool) {
+	switch data := v.(type) {
+	case *errors.CodeMsg:
+		return data, true
+	case errors.CodeMsg:
+		return &data, true
+	case *status.Status:
+		return errors.FromStatus(data), true
+	case interface{ GRPCStatus() *status.Status }:
+		return errors.FromStatus(data.GRPCStatus()), true
+	default:
+		return nil, false
+	}
+}
+
+// plainStatusError returns the error of v if v is a gRPC status error without the business code.
+func plainStatusError(v any) (error, bool) {
+	var st *status.Status
+	switch data := v.(type) {
+	case *errors.CodeMsg, errors.CodeMsg:
+		return nil, false
+	case *status.Status:
+		st = data
+	case interface{ GRPCStatus() *status.Status }:
+		st = data.GRPCStatus()
+	default:
+		return nil, false
+	}
+
+	if st.Code() == codes.OK || errors.HasBusinessCode(st) {
+		return nil, false
+	}
+
+	return st.Err(), true
+}
+
+// exposedMsg returns the message of cm, or BusinessMsgInternalError if it's unsafe to expose.
+func exposedMsg(cm *errors.CodeMsg) string {
+	if cm.Safe() {
+		return cm.Msg
+	}
+
+	return BusinessMsgInternalError
+}
+
+func setRetryAfter(w http.ResponseWriter, cm *errors.CodeMsg) {
+	if w == nil || !cm.Retryable() || cm.RetryAfter() <= 0 {
+		return
+	}
+
+	seconds := int64(math.Ceil(cm.RetryAfter().Seconds()))
+	w.Header().Set(retryAfterHeader, strconv.FormatInt(seconds, 10))
+}
+
+// logCodeMsg logs cm at the level of its severity with the given extra fields, like the correlation ID.
+func logCodeMsg(ctx context.Context, cm *errors.CodeMsg, extra ...logx.LogField) {
+	fields := []logx.LogField{
+		logx.Field("code", cm.Code),
+		logx.Field("category", cm.Category().String()),
+		logx.Field("severity", cm.Severity().String()),
+	}
+	fields = append(fields, extra...)
+	// %+v formats cm with its stack if captured
+	if len(cm.Caller()) > 0 {
+		fields = append(fields, logx.Field("stack", fmt.Sprintf("%+v", cm)))
+	}
+	logger := logx.WithContext(ctx)
+	switch cm.Severity() {
+	case errors.SeverityDebug:
+		logger.Debugw(cm.Msg, fields...)
+	case errors.SeverityInfo, errors.SeverityWarning:
+		logger.Infow(cm.Msg, fields...)
+	case errors.SeverityError, errors.SeverityCritical:
+		logger.Errorw(cm.Msg, fields...)
+	default:
+		// the server and dependency errors are not expected, and the redacted ones are only visible in the logs,
+		// log them with the stack even without severity
+		category := cm.Category()
+		if category == errors.CategoryServer || category == errors.CategoryDependency || !cm.Safe() {
+			logger.Errorw(cm.Msg, fields...)
+		}
+	}
+}
@@ -3,11 +3,14 @@ package http
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/zeromicro/go-zero/core/logx"
 	errorx "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/provider"
 	"github.com/zeromicro/x/test"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -53,6 +56,14 @@ func TestJsonBaseResponse(t *testing.T) {
 				writeString: `{"code":2,"msg":"Unknown"}`,
 			},
 		},
+		{
+			Name:  "status.Error-with-business-code",
+			Input: errorx.New(1001, "test").(*errorx.CodeMsg).GRPCStatus().Err(),
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":1001,"msg":"test"}`,
+			},
+		},
 		{
 			Name:  "error",
 			Input: errors.New("test"),
@@ -242,3 +253,25 @@ var comparisonOption = test.WithComparison[any, testWriterResult](func(t *testin
 	assert.Equal(t, expected.code, actual.code)
 	assert.Equal(t, expected.writeString, actual.writeString)
 })
+
+func TestBaseResponseErrorMetadata(t *testing.T) {
+	SetIDGenerator(test.NewSequenceIDGenerator("ref"))
+	defer SetIDGenerator(provider.UUIDGenerator)
+
+	for i, severity := range []errorx.Severity{errorx.SeverityDebug, errorx.SeverityInfo, errorx.SeverityError} {
+		w := &tracedResponseWriter{headers: make(map[string][]string)}
+		JsonBaseResponseCtx(context.Background(), w, errorx.New(1001, "dial tcp 10.0.0.1:3306",
+			errorx.WithCategory(errorx.CategoryDependency), errorx.WithSeverity(severity),
+			errorx.WithRetryable(1500*time.Millisecond)))
+		assert.JSONEq(t, `{"code":1001,"msg":"internal error","correlationId":"ref-`+
+			strconv.Itoa(i+1)+`"}`, w.builder.String())
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	}
+
+	w := &tracedResponseWriter{headers: make(map[string][]string)}
+	XmlBaseResponse(w, errorx.New(1001, "invalid name", errorx.WithCategory(errorx.CategoryClient),
+		errorx.WithRetryable(0)))
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>1001</code><msg>invalid name</msg></xml>`,
+		w.builder.String())
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/x/errors"
+)
+
+type (
+	// BatchResult is the data of the base response of a batch operation.
+	BatchResult struct {
+		Total  int         `json:"total" xml:"total"`
+		Failed int         `json:"failed" xml:"failed"`
+		Items  []BatchItem `json:"items" xml:"items>item"`
+	}
+
+	// BatchItem is the result of an item in a batch operation.
+	BatchItem struct {
+		Index int    `json:"index" xml:"index"`
+		Code  int    `json:"code" xml:"code"`
+		Msg   string `json:"msg" xml:"msg"`
+	}
+)
+
+// wrapBatchResponse renders batch as a base response with the result of every item,
+// the http status code is http.StatusMultiStatus if the batch partially succeeded.
+func wrapBatchResponse(ctx context.Context, batch *errors.BatchError) (int, BaseResponse[any]) {
+	failures := make(map[int]BatchItem, batch.Failed())
+	for _, item := range batch.Errors() {
+		failures[item.Index] = wrapBatchItem(ctx, item)
+	}
+
+	result := BatchResult{
+		Total: batch.Total(),
+		Items: make([]BatchItem, 0, batch.Total()),
+	}
+	for i := 0; i < batch.Total(); i++ {
+		item, ok := failures[i]
+		if ok {
+			result.Failed++
+		} else {
+			item = BatchItem{Index: i, Code: BusinessCodeOK, Msg: BusinessMsgOk}
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	resp := BaseResponse[any]{
+		Code: BusinessCodeOK,
+		Msg:  BusinessMsgOk,
+		Data: result,
+	}
+	if result.Failed > 0 {
+		resp.Code = batch.Code()
+		resp.Msg = fmt.Sprintf("%d of %d items failed", result.Failed, result.Total)
+	}
+	if result.Failed > 0 && result.Failed < result.Total {
+		return http.StatusMultiStatus, resp
+	}
+
+	return http.StatusOK, resp
+}
+
+func wrapBatchItem(ctx context.Context, item errors.ItemError) BatchItem {
+	if cm, ok := errors.FromError(item.Err); ok {
+		// the gRPC statuses without business codes are redacted like the plain errors
+		if !errors.HasBusinessCode(cm.GRPCStatus()) && shouldRedact(item.Err) {
+			logx.WithContext(ctx).Errorf("batch item %d: %+v", item.Index, item.Err)
+			return BatchItem{Index: item.Index, Code: cm.Code, Msg: BusinessMsgInternalError}
+		}
+
+		return BatchItem{Index: item.Index, Code: cm.Code, Msg: exposedMsg(cm)}
+	}
+
+	msg := item.Err.Error()
+	if shouldRedact(item.Err) {
+		logx.WithContext(ctx).Errorf("batch item %d: %+v", item.Index, item.Err)
+		msg = BusinessMsgInternalError
+	}
+
+	return BatchItem{Index: item.Index, Code: BusinessCodeError, Msg: msg}
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/test"
+)
+
+func TestBatchResponse(t *testing.T) {
+	partial := errorx.NewBatchError(3)
+	partial.Add(1, errorx.New(404, "not found"))
+	failed := errorx.NewBatchError(2)
+	failed.Add(0, errorx.New(404, "not found"))
+	failed.Add(1, errors.New("test"))
+	wrapped := errorx.NewBatchError(2)
+	wrapped.Add(0, fmt.Errorf("ctx: %w", errorx.New(1001, "bad")))
+
+	executor := test.NewExecutor[any, testWriterResult](comparisonOption)
+	executor.Add([]test.Data[any, testWriterResult]{
+		{
+			Name:  "succeeded",
+			Input: errorx.NewBatchError(1),
+			Want: testWriterResult{
+				code: http.StatusOK,
+				writeString: `{"code":0,"msg":"ok","data":{"total":1,"failed":0,` +
+					`"items":[{"index":0,"code":0,"msg":"ok"}]}}`,
+			},
+		},
+		{
+			Name:  "partial",
+			Input: partial,
+			Want: testWriterResult{
+				code: http.StatusMultiStatus,
+				writeString: `{"code":404,"msg":"1 of 3 items failed","data":{"total":3,"failed":1,"items":[` +
+					`{"index":0,"code":0,"msg":"ok"},{"index":1,"code":404,"msg":"not found"},` +
+					`{"index":2,"code":0,"msg":"ok"}]}}`,
+			},
+		},
+		{
+			Name:  "failed",
+			Input: failed,
+			Want: testWriterResult{
+				code: http.StatusOK,
+				writeString: `{"code":-1,"msg":"2 of 2 items failed","data":{"total":2,"failed":2,"items":[` +
+					`{"index":0,"code":404,"msg":"not found"},{"index":1,"code":-1,"msg":"test"}]}}`,
+			},
+		},
+		{
+			Name:  "wrapped",
+			Input: wrapped,
+			Want: testWriterResult{
+				code: http.StatusMultiStatus,
+				writeString: `{"code":1001,"msg":"1 of 2 items failed","data":{"total":2,"failed":1,"items":[` +
+					`{"index":0,"code":1001,"msg":"bad"},{"index":1,"code":0,"msg":"ok"}]}}`,
+			},
+		},
+	}...)
+	executor.RunE(t, func(a any) (testWriterResult, error) {
+		w := &tracedResponseWriter{headers: make(map[string][]string)}
+		JsonBaseResponseCtx(context.Background(), w, a)
+		return w.result()
+	})
+}
+
+func TestBatchResponseXml(t *testing.T) {
+	SetProductionMode(true)
+	defer SetProductionMode(false)
+
+	batch := errorx.NewBatchError(2)
+	batch.Add(0, errors.New("dial tcp 10.0.0.1:3306"))
+	w := &tracedResponseWriter{headers: make(map[string][]string)}
+	XmlBaseResponse(w, batch)
+	assert.Equal(t, http.StatusMultiStatus, w.code)
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>-1</code><msg>1 of 2 items failed</msg>`+
+		`<data><total>2</total><failed>1</failed><items>`+
+		`<item><index>0</index><code>-1</code><msg>internal error</msg></item>`+
+		`<item><index>1</index><code>0</code><msg>ok</msg></item></items></data></xml>`, w.builder.String())
+}
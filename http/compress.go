@@ -0,0 +1,424 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/x/internal/negotiate"
+)
+
+const (
+	// EncodingGzip represents the gzip content coding.
+	EncodingGzip = "gzip"
+	// EncodingDeflate represents the deflate content coding.
+	EncodingDeflate = "deflate"
+	// EncodingBrotli represents the brotli content coding, its encoder needs to be registered by RegisterEncoder.
+	EncodingBrotli = "br"
+	// EncodingZstd represents the zstd content coding, its encoder needs to be registered by RegisterEncoder.
+	EncodingZstd = "zstd"
+
+	acceptEncodingHeader  = "Accept-Encoding"
+	contentEncodingHeader = "Content-Encoding"
+	contentLengthHeader   = "Content-Length"
+	varyHeader            = "Vary"
+	defaultMinCompressLen = 1024
+)
+
+var (
+	encoders = map[string]EncoderFactory{
+		EncodingGzip: func(w io.Writer) (Encoder, error) {
+			return gzip.NewWriter(w), nil
+		},
+		EncodingDeflate: func(w io.Writer) (Encoder, error) {
+			return flate.NewWriter(w, flate.DefaultCompression)
+		},
+	}
+	// encodingPreference is the server preference of the encodings on a tie of the client qualities.
+	encodingPreference = []string{EncodingBrotli, EncodingZstd, EncodingGzip, EncodingDeflate}
+	encodersLock       sync.RWMutex
+
+	defaultCompressTypes = []string{
+		jsonContentType,
+		XmlContentType,
+		xmlTextContentType,
+		HTMLContentType,
+		"application/javascript",
+		"text/*",
+	}
+)
+
+type (
+	// Encoder compresses the data written into it, like gzip.Writer.
+	Encoder interface {
+		io.WriteCloser
+		Flush() error
+	}
+
+	// EncoderFactory creates an Encoder writing the compressed data into w.
+	EncoderFactory func(w io.Writer) (Encoder, error)
+
+	// CompressOption represents an option for CompressMiddleware.
+	CompressOption func(*compressOptions)
+
+	compressOptions struct {
+		minSize      int
+		contentTypes []string
+	}
+
+	compressWriter struct {
+		http.ResponseWriter
+		ctx      context.Context
+		encoding string
+		factory  EncoderFactory
+		options  *compressOptions
+		code     int
+		buf      []byte
+		decided  bool
+		encoder  Encoder
+		// err is the first error of compressing or writing, the later writes fail with it.
+		err error
+	}
+)
+
+// RegisterEncoder registers factory as the encoder of encoding, it replaces the existing one,
+// for example, brotli can be registered with github.com/andybalholm/brotli:
+//
+//	RegisterEncoder(EncodingBrotli, func(w io.Writer) (Encoder, error) {
+//		return brotli.NewWriter(w), nil
+//	})
+func RegisterEncoder(encoding string, factory EncoderFactory) {
+	encodersLock.Lock()
+	defer encodersLock.Unlock()
+
+	encoding = strings.ToLower(encoding)
+	encoders[encoding] = factory
+	for _, name := range encodingPreference {
+		if name == encoding {
+			return
+		}
+	}
+	encodingPreference = append(encodingPreference, encoding)
+}
+
+// WithMinCompressSize is an option to set the minimum body size to compress, defaults to 1024 bytes.
+func WithMinCompressSize(size int) CompressOption {
+	return func(o *compressOptions) {
+		o.minSize = size
+	}
+}
+
+// WithCompressTypes is an option to set the content types to compress, a type like text/* matches
+// all the subtypes. By default, json, xml, html, javascript and text are compressed.
+func WithCompressTypes(types ...string) CompressOption {
+	return func(o *compressOptions) {
+		o.contentTypes = types
+	}
+}
+
+// CompressMiddleware returns a middleware which compresses the responses with the encoding negotiated
+// from the Accept-Encoding header, gzip and deflate are built in, and the others can be registered
+// by RegisterEncoder. The responses smaller than the minimum size, or not in the content type allowlist,
+// are written uncompressed. Content-Length is removed from the compressed responses.
+// The bodies smaller than the minimum size are buffered until the handler returns, except the ones written
+// by the response helpers of this package, which are written at once to report the errors to the callers.
+// The errors after the handler returns are logged, and recorded by the ResponseWriter wrapped in the writer,
+// see RecordMiddleware.
+func CompressMiddleware(opts ...CompressOption) func(http.HandlerFunc) http.HandlerFunc {
+	o := compressOptions{
+		minSize:      defaultMinCompressLen,
+		contentTypes: defaultCompressTypes,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			// the HEAD responses vary by Accept-Encoding too, since they have the headers of the GET ones
+			w.Header().Add(varyHeader, acceptEncodingHeader)
+			if r.Method == http.MethodHead {
+				next(w, r)
+				return
+			}
+
+			encoding, factory, ok := negotiateEncoding(r.Header.Get(acceptEncodingHeader))
+			if !ok {
+				next(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				ctx:            r.Context(),
+				encoding:       encoding,
+				factory:        factory,
+				options:        &o,
+			}
+			defer cw.close()
+			next(cw.withFeatures(), r)
+		}
+	}
+}
+
+// WriteHeader records code, the header is written on the first write or when the handler returns.
+func (w *compressWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write buffers bs until the minimum size is reached, then writes them compressed if compressible.
+// It fails with the first error of the former writes.
+func (w *compressWriter) Write(bs []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if w.decided {
+		n, err := w.write(bs)
+		w.fail(err)
+		return n, err
+	}
+
+	w.buf = append(w.buf, bs...)
+	if len(w.buf) < w.options.minSize {
+		return len(bs), nil
+	}
+
+	if err := w.decide(true); err != nil {
+		return 0, err
+	}
+
+	return len(bs), nil
+}
+
+// Unwrap returns the wrapped writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withFeatures returns w with http.Flusher and http.Hijacker if the wrapped writer implements them.
+func (w *compressWriter) withFeatures() http.ResponseWriter {
+	_, canFlush := w.ResponseWriter.(http.Flusher)
+	_, canHijack := w.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case canFlush && canHijack:
+		return struct {
+			*compressWriter
+			http.Flusher
+			http.Hijacker
+		}{w, flusherFunc(w.flush), hijackerFunc(w.hijack)}
+	case canFlush:
+		return struct {
+			*compressWriter
+			http.Flusher
+		}{w, flusherFunc(w.flush)}
+	case canHijack:
+		return struct {
+			*compressWriter
+			http.Hijacker
+		}{w, hijackerFunc(w.hijack)}
+	default:
+		return w
+	}
+}
+
+// flush writes the buffered data, and flushes the encoder and the wrapped writer.
+// Nothing is flushed before the header or the body is written, so that the status code is still settable.
+func (w *compressWriter) flush() {
+	if !w.decided {
+		if err := w.decide(len(w.buf) > 0); err != nil || !w.decided {
+			return
+		}
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			w.fail(err)
+			return
+		}
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack hijacks the connection of the wrapped writer.
+func (w *compressWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.encoder != nil {
+		w.fail(w.encoder.Close())
+	}
+	if w.err == nil {
+		return
+	}
+
+	logx.WithContext(w.ctx).Errorf("compress response failed, error: %v", w.err)
+	if rw, ok := responseWriterOf(w.ResponseWriter); ok {
+		rw.setErr(w.err)
+	}
+}
+
+// fail records err as the error of w if it's the first one.
+func (w *compressWriter) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// finish writes the buffered body uncompressed, because it's smaller than the minimum size,
+// and returns the first error of the writes, it's called once the whole body is written.
+func (w *compressWriter) finish() error {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+
+	return w.err
+}
+
+// decide writes the header, compressed if compress is true and the response is compressible,
+// then writes the buffered data, it leaves w undecided if nothing is written yet.
+// The error is recorded into w.err.
+func (w *compressWriter) decide(compress bool) (err error) {
+	defer func() {
+		w.fail(err)
+	}()
+
+	// nothing is written yet, the status code defaults to http.StatusOK on the first write
+	if w.code == 0 && len(w.buf) == 0 {
+		return nil
+	}
+
+	w.decided = true
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+
+	if compress && w.compressible() {
+		encoder, err := w.factory(w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+
+		header := w.Header()
+		header.Set(contentEncodingHeader, w.encoding)
+		header.Del(contentLengthHeader)
+		w.encoder = encoder
+	}
+
+	w.ResponseWriter.WriteHeader(w.code)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	n, err := w.write(buf)
+	if err != nil {
+		return err
+	}
+	if n < len(buf) {
+		return io.ErrShortWrite
+	}
+
+	return nil
+}
+
+func (w *compressWriter) write(bs []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(bs)
+	}
+
+	return w.ResponseWriter.Write(bs)
+}
+
+func (w *compressWriter) compressible() bool {
+	if w.code < http.StatusOK || w.code == http.StatusNoContent || w.code == http.StatusNotModified {
+		return false
+	}
+
+	header := w.Header()
+	if len(header.Get(contentEncodingHeader)) > 0 {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get(httpx.ContentType))
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range w.options.contentTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// negotiateEncoding returns the registered encoding with the highest quality in the Accept-Encoding header,
+// the server preference is used on a tie.
+func negotiateEncoding(accept string) (string, EncoderFactory, bool) {
+	if len(accept) == 0 {
+		return "", nil, false
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		q, ok := negotiate.ParseQuality(params)
+		if !ok {
+			continue
+		}
+		qualities[name] = q
+	}
+
+	encodersLock.RLock()
+	defer encodersLock.RUnlock()
+
+	var best string
+	var bestQ float64
+	for _, name := range encodingPreference {
+		if _, ok := encoders[name]; !ok {
+			continue
+		}
+
+		q, ok := qualities[name]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	if len(best) == 0 {
+		return "", nil, false
+	}
+
+	return best, encoders[best], true
+}
@@ -0,0 +1,270 @@
+package http
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopEncoder struct {
+	io.Writer
+}
+
+func (e nopEncoder) Flush() error {
+	return nil
+}
+
+func (e nopEncoder) Close() error {
+	return nil
+}
+
+type failedEncoder struct {
+	nopEncoder
+}
+
+func (e failedEncoder) Close() error {
+	return errors.New("close failed")
+}
+
+func serveCompressed(handler http.HandlerFunc, acceptEncoding string,
+	opts ...CompressOption) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	if len(acceptEncoding) > 0 {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	CompressMiddleware(opts...)(handler)(w, r)
+	return w
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	names := make([]message, 100)
+	for i := range names {
+		names[i] = message{Name: "anyone"}
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		OkXml(w, names)
+	}
+	plain := serveCompressed(handler, "").Body.String()
+
+	w := serveCompressed(handler, "deflate;q=0.5, gzip")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, XmlContentType, w.Header().Get("Content-Type"))
+	reader, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, string(body))
+
+	w = serveCompressed(handler, "deflate")
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+	body, err = io.ReadAll(flate.NewReader(w.Body))
+	assert.NoError(t, err)
+	assert.Equal(t, plain, string(body))
+
+	w = serveCompressed(handler, "gzip;q=0, br")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, plain, w.Body.String())
+}
+
+func TestCompressMiddlewareSkipped(t *testing.T) {
+	w := serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		OkXml(w, message{Name: "anyone"})
+	}, "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "<data><name>anyone</name></data>", w.Body.String())
+
+	w = serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", "2048")
+		_, _ = w.Write(make([]byte, 2048))
+	}, "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "2048", w.Header().Get("Content-Length"))
+	assert.Equal(t, 2048, w.Body.Len())
+
+	w = serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}, "gzip")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serveCompressed(func(w http.ResponseWriter, r *http.Request) {}, "gzip")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, w.Flushed)
+}
+
+func TestCompressMiddlewareOptions(t *testing.T) {
+	w := serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "4")
+		OkHTML(w, "<p/>")
+	}, "*", WithMinCompressSize(1), WithCompressTypes("application/*"))
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+
+	w = serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		OkHTML(w, "<p/>")
+	}, "gzip", WithMinCompressSize(1), WithCompressTypes("text/html"))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "<p/>", w.Body.String())
+}
+
+func TestCompressMiddlewareFlush(t *testing.T) {
+	w := serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	}, "gzip")
+	assert.True(t, w.Flushed)
+	reader, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", string(body))
+}
+
+func TestCompressMiddlewareWriteErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set("Accept-Encoding", "gzip")
+	var err error
+	CompressMiddleware(WithMinCompressSize(1))(func(w http.ResponseWriter, r *http.Request) {
+		err = doWriteHTML(w, http.StatusOK, "<p/>")
+	})(failedWriter{httptest.NewRecorder()}, r)
+	assert.Error(t, err)
+
+	CompressMiddleware(WithMinCompressSize(1), WithCompressTypes("text/*"))(
+		func(w http.ResponseWriter, r *http.Request) {
+			err = doWriteHTML(w, http.StatusOK, "<p/>")
+		})(failedWriter{httptest.NewRecorder()}, r)
+	assert.EqualError(t, err, "write response failed, error: broken pipe")
+
+	// the small bodies are written at once by the response helpers
+	CompressMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		err = doWriteHTML(w, http.StatusOK, "<p/>")
+		assert.Error(t, doWriteHTML(w, http.StatusOK, "<p/>"))
+	})(failedWriter{httptest.NewRecorder()}, r)
+	assert.EqualError(t, err, "write response failed, error: broken pipe")
+
+	rw := NewResponseWriter(httptest.NewRecorder())
+	CompressMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		JsonBaseResponse(w, message{Name: "anyone"})
+	})(rw, r)
+	assert.Equal(t, BusinessCodeOK, rw.Record().Code)
+	assert.True(t, rw.Record().HasCode)
+	assert.NoError(t, rw.Record().Err)
+}
+
+func TestCompressMiddlewareCloseError(t *testing.T) {
+	RegisterEncoder("x-fail", func(w io.Writer) (Encoder, error) {
+		return failedEncoder{nopEncoder{Writer: w}}, nil
+	})
+	defer func() {
+		encodersLock.Lock()
+		delete(encoders, "x-fail")
+		encodingPreference = encodingPreference[:len(encodingPreference)-1]
+		encodersLock.Unlock()
+	}()
+
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set("Accept-Encoding", "x-fail")
+	rw := NewResponseWriter(httptest.NewRecorder())
+	CompressMiddleware(WithMinCompressSize(1))(func(w http.ResponseWriter, r *http.Request) {
+		OkHTML(w, "<p/>")
+	})(rw, r)
+	assert.EqualError(t, rw.Record().Err, "close failed")
+}
+
+func TestCompressMiddlewareHead(t *testing.T) {
+	r := httptest.NewRequest(http.MethodHead, "/", http.NoBody)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	CompressMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(*compressWriter)
+		assert.False(t, ok)
+	})(w, r)
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+}
+
+func TestCompressMiddlewareFlushBeforeHeader(t *testing.T) {
+	w := serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		w.WriteHeader(http.StatusCreated)
+		OkHTML(w, "<p/>")
+	}, "gzip")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "<p/>", w.Body.String())
+}
+
+func TestCompressMiddlewareFeatures(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set("Accept-Encoding", "gzip")
+	CompressMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Flusher)
+		assert.False(t, ok)
+		_, ok = w.(http.Hijacker)
+		assert.False(t, ok)
+	})(shortWriter{httptest.NewRecorder()}, r)
+
+	CompressMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Flusher)
+		assert.True(t, ok)
+		_, _, err := w.(http.Hijacker).Hijack()
+		assert.EqualError(t, err, "not supported")
+	})(hijackWriter{httptest.NewRecorder()}, r)
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	RegisterEncoder("X-Test", func(w io.Writer) (Encoder, error) {
+		return nopEncoder{Writer: w}, nil
+	})
+	RegisterEncoder(EncodingBrotli, func(w io.Writer) (Encoder, error) {
+		return nil, errors.New("unavailable")
+	})
+	defer func() {
+		encodersLock.Lock()
+		delete(encoders, "x-test")
+		delete(encoders, EncodingBrotli)
+		encodingPreference = encodingPreference[:len(encodingPreference)-1]
+		encodersLock.Unlock()
+	}()
+
+	body := strings.Repeat("a", 2048)
+	w := serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		OkHTML(w, body)
+	}, "x-test")
+	assert.Equal(t, "x-test", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, w.Body.String())
+
+	var err error
+	serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+		err = doWriteHTML(w, http.StatusOK, body)
+	}, "br, gzip")
+	assert.Error(t, err)
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	encoding, _, ok := negotiateEncoding("gzip;q=0.5, deflate;q=0.8")
+	assert.True(t, ok)
+	assert.Equal(t, EncodingDeflate, encoding)
+
+	encoding, _, ok = negotiateEncoding("deflate, gzip")
+	assert.True(t, ok)
+	assert.Equal(t, EncodingGzip, encoding)
+
+	encoding, _, ok = negotiateEncoding("gzip;level=1;q=0.5, deflate; Q=0.8")
+	assert.True(t, ok)
+	assert.Equal(t, EncodingDeflate, encoding)
+
+	_, _, ok = negotiateEncoding("identity, gzip;q=abc")
+	assert.False(t, ok)
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+const (
+	etagHeader            = "ETag"
+	ifNoneMatchHeader     = "If-None-Match"
+	ifModifiedSinceHeader = "If-Modified-Since"
+	lastModifiedHeader    = "Last-Modified"
+	weakETagPrefix        = "W/"
+	// etagHashLen is the number of the hash bytes used in the generated ETags.
+	etagHashLen = 16
+)
+
+type (
+	// ConditionalOption represents an option for the conditional writers.
+	ConditionalOption func(*conditionalOptions)
+
+	conditionalOptions struct {
+		etag         string
+		weak         bool
+		lastModified time.Time
+	}
+)
+
+// WithETag is an option to use etag instead of the one computed from the encoded body,
+// it's quoted if not yet.
+func WithETag(etag string) ConditionalOption {
+	return func(o *conditionalOptions) {
+		o.etag = etag
+	}
+}
+
+// WithWeakETag is an option to mark the ETag as weak, which means the responses are semantically
+// equivalent but may not be byte-for-byte identical.
+func WithWeakETag() ConditionalOption {
+	return func(o *conditionalOptions) {
+		o.weak = true
+	}
+}
+
+// WithLastModified is an option to set the Last-Modified header,
+// which is used to evaluate the If-Modified-Since header.
+func WithLastModified(t time.Time) ConditionalOption {
+	return func(o *conditionalOptions) {
+		o.lastModified = t
+	}
+}
+
+// ConditionalOkJson writes v as json into w with 200 OK, and an ETag computed from the encoded body.
+// It responds 304 Not Modified without a body if the conditional headers of r match.
+func ConditionalOkJson(w http.ResponseWriter, r *http.Request, v any, opts ...ConditionalOption) {
+	if err := doWriteConditional(w, r, http.StatusOK, httpx.JsonContentType, json.Marshal, v, true, opts...); err != nil {
+		logx.WithContext(r.Context()).Error(err)
+	}
+}
+
+// ConditionalOkXml writes v as xml into w with 200 OK, and an ETag computed from the encoded body.
+// It responds 304 Not Modified without a body if the conditional headers of r match.
+func ConditionalOkXml(w http.ResponseWriter, r *http.Request, v any, opts ...ConditionalOption) {
+	if err := doWriteConditional(w, r, http.StatusOK, XmlContentType, xml.Marshal, v, true, opts...); err != nil {
+		logx.WithContext(r.Context()).Error(err)
+	}
+}
+
+// ConditionalJsonBaseResponse writes v into w as JsonBaseResponseCtx does, with an ETag computed
+// from the encoded body. It responds 304 Not Modified without a body if the conditional headers of r match.
+// The error responses are written without the conditional headers.
+func ConditionalJsonBaseResponse(w http.ResponseWriter, r *http.Request, v any, opts ...ConditionalOption) {
+	ctx := r.Context()
+	code, resp := prepareBaseResponse(ctx, w, v)
+	opts = withStableETag(ctx, json.Marshal, resp, opts)
+	contentType := envelopeContentType(ctx, "json", httpx.JsonContentType)
+	err := doWriteConditional(w, r, code, contentType, json.Marshal, jsonEnvelope(ctx, resp),
+		resp.Code == BusinessCodeOK, opts...)
+	if err != nil {
+		logx.WithContext(ctx).Error(err)
+	}
+	observeBaseResponse(w, code, resp.Code)
+}
+
+// ConditionalXmlBaseResponse writes v into w as XmlBaseResponseCtx does, with an ETag computed
+// from the encoded body. It responds 304 Not Modified without a body if the conditional headers of r match.
+// The error responses are written without the conditional headers.
+func ConditionalXmlBaseResponse(w http.ResponseWriter, r *http.Request, v any, opts ...ConditionalOption) {
+	ctx := r.Context()
+	code, resp := prepareBaseResponse(ctx, w, v)
+	opts = withStableETag(ctx, xml.Marshal, resp, opts)
+	contentType := envelopeContentType(ctx, "xml", XmlContentType)
+	err := doWriteConditional(w, r, code, contentType, xml.Marshal, xmlEnvelope(ctx, resp),
+		resp.Code == BusinessCodeOK, opts...)
+	if err != nil {
+		logx.WithContext(ctx).Error(err)
+	}
+	observeBaseResponse(w, code, resp.Code)
+}
+
+func doWriteConditional(w http.ResponseWriter, r *http.Request, code int, contentType string,
+	marshal func(any) ([]byte, error), v any, cacheable bool, opts ...ConditionalOption) error {
+	var o conditionalOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	bs, err := marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return fmt.Errorf("marshal response failed, error: %w", err)
+	}
+
+	// only the successful responses are cacheable.
+	if code != http.StatusOK || !cacheable {
+		return writeBytes(w, code, contentType, bs)
+	}
+
+	header := w.Header()
+	etag := o.etag
+	if len(etag) == 0 {
+		etag = computeETag(bs)
+	}
+	etag = formatETag(etag, o.weak)
+	header.Set(etagHeader, etag)
+	if !o.lastModified.IsZero() {
+		header.Set(lastModifiedHeader, o.lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, o.lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	return writeBytes(w, code, contentType, bs)
+}
+
+// withStableETag prepends the ETag computed from the version and the v1 envelope of resp to opts
+// if the version of ctx is VersionV2, because the metadata of the v2 envelope changes on every response.
+// The ETag is weak, since the v2 responses with the same ETag are not byte-for-byte identical.
+func withStableETag(ctx context.Context, marshal func(any) ([]byte, error), resp BaseResponse[any],
+	opts []ConditionalOption) []ConditionalOption {
+	version := VersionFromContext(ctx)
+	if version != VersionV2 {
+		return opts
+	}
+
+	bs, err := marshal(resp)
+	if err != nil {
+		return opts
+	}
+
+	etag := computeETag(append([]byte(version.String()+":"), bs...))
+	return append(append([]ConditionalOption{WithETag(etag)}, opts...), WithWeakETag())
+}
+
+// notModified evaluates the conditional headers of r as RFC 9110 section 13.2.2,
+// If-Modified-Since is ignored if If-None-Match is present.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get(ifNoneMatchHeader); len(inm) > 0 {
+		return etagMatch(inm, etag)
+	}
+
+	ims := r.Header.Get(ifModifiedSinceHeader)
+	if len(ims) == 0 || lastModified.IsZero() {
+		return false
+	}
+
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(t)
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag with the weak comparison.
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, weakETagPrefix)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, weakETagPrefix) == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func computeETag(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:etagHashLen])
+}
+
+func formatETag(etag string, weak bool) string {
+	if strings.HasPrefix(etag, weakETagPrefix) {
+		return etag
+	}
+	if !strings.HasPrefix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	if weak {
+		return weakETagPrefix + etag
+	}
+
+	return etag
+}
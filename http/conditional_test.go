@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+)
+
+func TestConditionalJsonBaseResponse(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	w := httptest.NewRecorder()
+	ConditionalJsonBaseResponse(w, r, message{Name: "anyone"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"code":0,"msg":"ok","data":{"name":"anyone"}}`, w.Body.String())
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+
+	r.Header.Set("If-None-Match", `"other", `+etag)
+	w = httptest.NewRecorder()
+	ConditionalJsonBaseResponse(w, r, message{Name: "anyone"})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	ConditionalJsonBaseResponse(w, r, message{Name: "someone"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	ConditionalJsonBaseResponse(w, r, errorx.New(1001, "test"))
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Equal(t, `{"code":1001,"msg":"test"}`, w.Body.String())
+}
+
+func TestConditionalXmlBaseResponse(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set("If-None-Match", `"v1"`)
+	w := httptest.NewRecorder()
+	ConditionalXmlBaseResponse(w, r, message{Name: "anyone"}, WithETag("v1"), WithWeakETag())
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"))
+
+	r.Method = http.MethodPost
+	w = httptest.NewRecorder()
+	ConditionalXmlBaseResponse(w, r, message{Name: "anyone"}, WithETag(`W/"v1"`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>0</code><msg>ok</msg><data><name>anyone</name></data></xml>`,
+		w.Body.String())
+}
+
+func TestConditionalLastModified(t *testing.T) {
+	modified := time.Date(2023, 1, 2, 3, 4, 5, 600, time.UTC)
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+	w := httptest.NewRecorder()
+	ConditionalOkJson(w, r, message{Name: "anyone"}, WithLastModified(modified))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, "Mon, 02 Jan 2023 03:04:05 GMT", w.Header().Get("Last-Modified"))
+
+	w = httptest.NewRecorder()
+	ConditionalOkJson(w, r, message{Name: "anyone"}, WithLastModified(modified.Add(time.Second)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"name":"anyone"}`, w.Body.String())
+
+	// If-None-Match takes precedence
+	r.Header.Set("If-None-Match", `"other"`)
+	w = httptest.NewRecorder()
+	ConditionalOkXml(w, r, message{Name: "anyone"}, WithLastModified(modified))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<data><name>anyone</name></data>", w.Body.String())
+
+	r.Header.Del("If-None-Match")
+	r.Header.Set("If-Modified-Since", "invalid")
+	w = httptest.NewRecorder()
+	ConditionalOkJson(w, r, message{Name: "anyone"}, WithLastModified(modified))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestConditionalMarshalFailed(t *testing.T) {
+	w := httptest.NewRecorder()
+	ConditionalOkJson(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody), complex(0, 0))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestETagMatch(t *testing.T) {
+	assert.True(t, etagMatch("*", `"a"`))
+	assert.True(t, etagMatch(`W/"a"`, `"a"`))
+	assert.True(t, etagMatch(`"a"`, `W/"a"`))
+	assert.False(t, etagMatch(`"b"`, `"a"`))
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sync"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/x/errors"
+)
+
+// ErrorHandler converts err into the http status code and the value rendered as the base response,
+// the value is usually err itself or an errors.CodeMsg converted from err.
+type ErrorHandler func(ctx context.Context, err error) (int, any)
+
+type (
+	errorHandlerKey   struct{}
+	responseWriterKey struct{}
+)
+
+var (
+	errorHandler     ErrorHandler = DefaultErrorHandler
+	errorHandlerLock sync.RWMutex
+)
+
+// DefaultErrorHandler responds errors.BatchError with http.StatusMultiStatus if it's partially succeeded,
+// otherwise http.StatusOK, as JsonBaseResponse does, errors.CodeMsg with the http status of its code
+// in the errors catalog, the other errors.CodeMsg and gRPC status with http.StatusOK,
+// and the other errors, like the request parse failures, with http.StatusBadRequest, as httpx.Error does,
+// they're wrapped by errors.InvalidParams, so that their messages are exposed in production mode.
+func DefaultErrorHandler(_ context.Context, err error) (int, any) {
+	// the batch is checked first, otherwise errors.HTTPStatus finds the status of a failure in it
+	var batch *errors.BatchError
+	if stderrors.As(err, &batch) {
+		if batch.PartialSuccess() {
+			return http.StatusMultiStatus, batch
+		}
+		return http.StatusOK, batch
+	}
+	if status, ok := errors.HTTPStatus(err); ok {
+		return status, err
+	}
+	if _, ok := codeMsgOf(err); ok {
+		return http.StatusOK, err
+	}
+
+	// the messages of the parse failures are meant for the clients, they must not be redacted in production mode
+	return http.StatusBadRequest, errors.InvalidParams(err.Error())
+}
+
+// SetupErrorHandler installs handler as the error handler of httpx.Error, httpx.ErrorCtx, XmlError and XmlErrorCtx,
+// so that all the errors are rendered as the base responses. DefaultErrorHandler is used if handler is nil.
+// The handler can be overridden per route by WithErrorHandler, which only takes effect on httpx.ErrorCtx
+// and XmlErrorCtx, because the others have no request context.
+// Since the error handlers of httpx have no response writer, httpx.ErrorCtx sets the Retry-After header
+// and records the business code on the writer installed into the request context by RecordMiddleware,
+// RouteMiddleware or WithErrorHandler, httpx.Error sets neither.
+func SetupErrorHandler(handler ErrorHandler) {
+	if handler == nil {
+		handler = DefaultErrorHandler
+	}
+
+	errorHandlerLock.Lock()
+	errorHandler = handler
+	errorHandlerLock.Unlock()
+
+	httpx.SetErrorHandler(func(err error) (int, any) {
+		return handleError(context.Background(), err)
+	})
+	httpx.SetErrorHandlerCtx(handleError)
+}
+
+// WithErrorHandler returns a middleware which overrides the error handler for the routes it's applied to,
+// for example:
+//
+//	server.AddRoutes(routes, rest.WithMiddlewares([]rest.Middleware{WithErrorHandler(handler)}))
+func WithErrorHandler(handler ErrorHandler) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), errorHandlerKey{}, handler)
+			next(w, withResponseWriter(r.WithContext(ctx), w))
+		}
+	}
+}
+
+// XmlError writes err into w as an xml base response with the installed error handler.
+func XmlError(w http.ResponseWriter, err error) {
+	XmlErrorCtx(context.Background(), w, err)
+}
+
+// XmlErrorCtx writes err into w as an xml base response with the installed error handler,
+// or the one set by WithErrorHandler.
+func XmlErrorCtx(ctx context.Context, w http.ResponseWriter, err error) {
+	code, v := resolveErrorHandler(ctx)(ctx, err)
+	writeBaseResponse(ctx, w, code, v, true)
+}
+
+func handleError(ctx context.Context, err error) (int, any) {
+	code, v := resolveErrorHandler(ctx)(ctx, err)
+	w := responseWriterFromContext(ctx)
+	_, resp := prepareBaseResponse(ctx, w, v)
+	observeBaseResponse(w, code, resp.Code)
+	return code, jsonEnvelope(ctx, resp)
+}
+
+// withResponseWriter returns r with w in its context, so that the error handlers of httpx can reach w.
+func withResponseWriter(r *http.Request, w http.ResponseWriter) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), responseWriterKey{}, w))
+}
+
+// responseWriterFromContext returns the writer set by withResponseWriter, or nil if absent.
+func responseWriterFromContext(ctx context.Context) http.ResponseWriter {
+	w, _ := ctx.Value(responseWriterKey{}).(http.ResponseWriter)
+	return w
+}
+
+func resolveErrorHandler(ctx context.Context) ErrorHandler {
+	if handler, ok := ctx.Value(errorHandlerKey{}).(ErrorHandler); ok && handler != nil {
+		return handler
+	}
+
+	errorHandlerLock.RLock()
+	defer errorHandlerLock.RUnlock()
+	return errorHandler
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	errorx "github.com/zeromicro/x/errors"
+)
+
+func TestSetupErrorHandler(t *testing.T) {
+	SetupErrorHandler(nil)
+	defer resetErrorHandler()
+
+	w := httptest.NewRecorder()
+	httpx.Error(w, errors.New("field name is not set"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"code":40000,"msg":"field name is not set"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	httpx.ErrorCtx(context.Background(), w, errorx.New(1001, "test"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"code":1001,"msg":"test"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	XmlError(w, errors.New("field name is not set"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>40000</code><msg>field name is not set</msg></xml>`,
+		w.Body.String())
+}
+
+func TestSetupErrorHandlerProductionMode(t *testing.T) {
+	SetupErrorHandler(nil)
+	SetProductionMode(true)
+	defer func() {
+		SetProductionMode(false)
+		resetErrorHandler()
+	}()
+
+	w := httptest.NewRecorder()
+	httpx.ErrorCtx(context.Background(), w, errors.New("field name is not set"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"code":40000,"msg":"field name is not set"}`, w.Body.String())
+}
+
+func TestSetupErrorHandlerCustom(t *testing.T) {
+	SetupErrorHandler(func(ctx context.Context, err error) (int, any) {
+		return http.StatusUnprocessableEntity, errorx.New(4220, err.Error())
+	})
+	defer resetErrorHandler()
+
+	w := httptest.NewRecorder()
+	httpx.ErrorCtx(context.Background(), w, errors.New("invalid"))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"code":4220,"msg":"invalid"}`, w.Body.String())
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	SetupErrorHandler(nil)
+	defer resetErrorHandler()
+
+	override := WithErrorHandler(func(ctx context.Context, err error) (int, any) {
+		return http.StatusConflict, errorx.New(409, "conflict")
+	})
+	handler := override(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "xml" {
+			XmlErrorCtx(r.Context(), w, errors.New("test"))
+		} else {
+			httpx.ErrorCtx(r.Context(), w, errors.New("test"))
+		}
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, `{"code":409,"msg":"conflict"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/?format=xml", http.NoBody))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>409</code><msg>conflict</msg></xml>`,
+		w.Body.String())
+}
+
+func resetErrorHandler() {
+	httpx.SetErrorHandler(nil)
+	httpx.SetErrorHandlerCtx(nil)
+	errorHandlerLock.Lock()
+	errorHandler = DefaultErrorHandler
+	errorHandlerLock.Unlock()
+}
+
+func TestDefaultErrorHandlerCatalog(t *testing.T) {
+	SetupErrorHandler(nil)
+	defer resetErrorHandler()
+
+	w := httptest.NewRecorder()
+	httpx.ErrorCtx(context.Background(), w, errorx.NotFound("user not found"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, `{"code":40400,"msg":"user not found"}`, w.Body.String())
+}
+
+func TestDefaultErrorHandlerBatch(t *testing.T) {
+	SetupErrorHandler(nil)
+	defer resetErrorHandler()
+
+	partial := errorx.NewBatchError(2)
+	partial.Add(0, errorx.NotFound("user not found"))
+	w := httptest.NewRecorder()
+	httpx.ErrorCtx(context.Background(), w, partial)
+	assert.Equal(t, http.StatusMultiStatus, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":40400,"msg":"1 of 2 items failed"`)
+
+	failed := errorx.NewBatchError(1)
+	failed.Add(0, errors.New("test"))
+	w = httptest.NewRecorder()
+	httpx.ErrorCtx(context.Background(), w, failed)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":-1,"msg":"1 of 1 items failed"`)
+}
+
+func TestErrorHandlerResponseWriter(t *testing.T) {
+	SetupErrorHandler(nil)
+	defer resetErrorHandler()
+
+	var record ResponseRecord
+	handler := RecordMiddleware(func(r *http.Request, rec ResponseRecord) {
+		record = rec
+	})(func(w http.ResponseWriter, r *http.Request) {
+		httpx.ErrorCtx(r.Context(), w, errorx.RateLimited("", errorx.WithRetryable(2*time.Second)))
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.True(t, record.HasCode)
+	assert.Equal(t, 42900, record.Code)
+}
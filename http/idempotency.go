@@ -0,0 +1,426 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/x/errors"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set to true on the replayed responses.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultIdempotencyLockTTL = time.Minute
+	idempotencyKeyPrefix      = "idempotency:"
+	idempotencyPending        = "-"
+)
+
+// idempotencyExcludedHeaders are the response headers which are specific to a request,
+// they're not stored, so that the replays never leak the cookies or the traces of the first request.
+var idempotencyExcludedHeaders = []string{"Set-Cookie", "Traceparent", "Tracestate", "X-Trace-Id"}
+
+type (
+	// IdempotentResponse is the stored response of an idempotent request.
+	IdempotentResponse struct {
+		Status int         `json:"status"`
+		Header http.Header `json:"header,omitempty"`
+		Body   []byte      `json:"body,omitempty"`
+		// RequestHash is the hash of the request, the requests reusing the key must have the same hash.
+		RequestHash string `json:"requestHash,omitempty"`
+	}
+
+	// IdempotencyStore stores the responses of the idempotent requests.
+	IdempotencyStore interface {
+		// Reserve marks key as in progress for ttl,
+		// it returns false if key is already in progress or completed.
+		Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+		// Get returns the response of key, or nil if key is absent or in progress.
+		Get(ctx context.Context, key string) (*IdempotentResponse, error)
+		// Save stores the response of key for ttl, which completes key.
+		Save(ctx context.Context, key string, resp *IdempotentResponse, ttl time.Duration) error
+		// Delete removes key, so that the request can be retried.
+		Delete(ctx context.Context, key string) error
+	}
+
+	// IdempotencyOption represents an option for IdempotencyMiddleware.
+	IdempotencyOption func(*idempotencyOptions)
+
+	// MemoryIdempotencyStore is an in-memory IdempotencyStore, it's usually used in tests.
+	MemoryIdempotencyStore struct {
+		lock    sync.Mutex
+		entries map[string]memoryIdempotencyEntry
+	}
+
+	// RedisIdempotencyStore is an IdempotencyStore built on go-zero redis.
+	RedisIdempotencyStore struct {
+		rds *redis.Redis
+	}
+
+	idempotencyOptions struct {
+		ttl      time.Duration
+		lockTTL  time.Duration
+		identity func(r *http.Request) string
+		required bool
+	}
+
+	memoryIdempotencyEntry struct {
+		resp     *IdempotentResponse
+		expireAt time.Time
+	}
+
+	captureWriter struct {
+		http.ResponseWriter
+		status int
+		header http.Header
+		body   []byte
+	}
+)
+
+// WithIdempotencyTTL is an option to set how long the responses are stored, defaults to 24 hours.
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithIdempotencyLockTTL is an option to set how long a key is reserved while its request is in progress,
+// it should be longer than the request timeout, defaults to 1 minute.
+func WithIdempotencyLockTTL(ttl time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.lockTTL = ttl
+	}
+}
+
+// WithIdempotencyIdentity is an option to set the function returning the caller identity of r,
+// like the user ID, the keys of different callers never collide. It's required by IdempotencyMiddleware,
+// the anonymous endpoints can use the client addresses or a fixed value as the identity.
+func WithIdempotencyIdentity(fn func(r *http.Request) string) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.identity = fn
+	}
+}
+
+// WithIdempotencyRequired is an option to reject the requests without the Idempotency-Key header.
+func WithIdempotencyRequired() IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.required = true
+	}
+}
+
+// IdempotencyMiddleware returns a middleware which stores the first response of the requests
+// with the Idempotency-Key header in store, keyed by the key, the method, the path and the caller identity.
+// The retries get the stored response replayed with the Idempotent-Replayed header,
+// and a concurrent duplicate gets an errors.KindConflict base response with http.StatusConflict.
+// A retry with a different query or body gets an errors.KindInvalidParams base response
+// with http.StatusUnprocessableEntity. The responses with 5xx status are not stored, so that they can be retried.
+// It panics if the identity is not set by WithIdempotencyIdentity, and the requests with an empty identity
+// get an errors.KindUnauthorized base response.
+func IdempotencyMiddleware(store IdempotencyStore, opts ...IdempotencyOption) func(http.HandlerFunc) http.HandlerFunc {
+	o := idempotencyOptions{
+		ttl:     defaultIdempotencyTTL,
+		lockTTL: defaultIdempotencyLockTTL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.identity == nil {
+		panic("idempotency identity is required")
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if len(key) == 0 {
+				if o.required {
+					writeBaseResponse(ctx, w, http.StatusBadRequest,
+						errors.InvalidParams(IdempotencyKeyHeader+" header is required"), wantsXml(r))
+					return
+				}
+
+				next(w, r)
+				return
+			}
+
+			identity := o.identity(r)
+			if len(identity) == 0 {
+				writeBaseResponse(ctx, w, http.StatusUnauthorized,
+					errors.Unauthorized("caller identity is required"), wantsXml(r))
+				return
+			}
+			key = idempotencyKeyPrefix + hashIdempotency(identity, r.Method, r.URL.Path, key)
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeBaseResponse(ctx, w, http.StatusBadRequest,
+					errors.InvalidParams("read request body failed"), wantsXml(r))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := hashIdempotency(r.URL.RawQuery, string(body))
+
+			if replayed, err := replayIdempotent(w, r, store, key, hash); err != nil || replayed {
+				if err != nil {
+					writeIdempotencyError(w, r, err)
+				}
+				return
+			}
+
+			reserved, err := store.Reserve(ctx, key, o.lockTTL)
+			if err != nil {
+				writeIdempotencyError(w, r, err)
+				return
+			}
+			if !reserved {
+				// the request may be completed between Get and Reserve
+				if replayed, err := replayIdempotent(w, r, store, key, hash); err != nil || replayed {
+					if err != nil {
+						writeIdempotencyError(w, r, err)
+					}
+					return
+				}
+
+				writeBaseResponse(ctx, w, http.StatusConflict,
+					errors.Conflict("a request with the same idempotency key is in progress"), wantsXml(r))
+				return
+			}
+
+			cw := &captureWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := store.Delete(context.Background(), key); err != nil {
+					logx.WithContext(ctx).Errorf("delete idempotency key %s failed, error: %v", key, err)
+				}
+			}()
+
+			next(cw, r)
+
+			if cw.status == 0 || cw.status >= http.StatusInternalServerError {
+				return
+			}
+			resp := cw.response()
+			resp.RequestHash = hash
+			if err := store.Save(ctx, key, resp, o.ttl); err != nil {
+				logx.WithContext(ctx).Errorf("save idempotency key %s failed, error: %v", key, err)
+				return
+			}
+			completed = true
+		}
+	}
+}
+
+// NewMemoryIdempotencyStore creates a MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries: make(map[string]memoryIdempotencyEntry),
+	}
+}
+
+// Reserve marks key as in progress for ttl.
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.get(key); ok {
+		return false, nil
+	}
+
+	s.entries[key] = memoryIdempotencyEntry{expireAt: now().Add(ttl)}
+	return true, nil
+}
+
+// Get returns the response of key.
+func (s *MemoryIdempotencyStore) Get(_ context.Context, key string) (*IdempotentResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, _ := s.get(key)
+	return entry.resp, nil
+}
+
+// Save stores the response of key for ttl.
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, resp *IdempotentResponse,
+	ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.entries[key] = memoryIdempotencyEntry{resp: resp, expireAt: now().Add(ttl)}
+	return nil
+}
+
+// Delete removes key.
+func (s *MemoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) get(key string) (memoryIdempotencyEntry, bool) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return entry, false
+	}
+	if !now().Before(entry.expireAt) {
+		delete(s.entries, key)
+		return memoryIdempotencyEntry{}, false
+	}
+
+	return entry, true
+}
+
+// NewRedisIdempotencyStore creates a RedisIdempotencyStore with rds.
+func NewRedisIdempotencyStore(rds *redis.Redis) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{rds: rds}
+}
+
+// Reserve marks key as in progress for ttl.
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.rds.SetnxExCtx(ctx, key, idempotencyPending, ttlSeconds(ttl))
+}
+
+// Get returns the response of key.
+func (s *RedisIdempotencyStore) Get(ctx context.Context, key string) (*IdempotentResponse, error) {
+	val, err := s.rds.GetCtx(ctx, key)
+	if err != nil || len(val) == 0 || val == idempotencyPending {
+		return nil, err
+	}
+
+	var resp IdempotentResponse
+	if err := json.Unmarshal([]byte(val), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// Save stores the response of key for ttl.
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, resp *IdempotentResponse,
+	ttl time.Duration) error {
+	val, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return s.rds.SetexCtx(ctx, key, string(val), ttlSeconds(ttl))
+}
+
+// Delete removes key.
+func (s *RedisIdempotencyStore) Delete(ctx context.Context, key string) error {
+	_, err := s.rds.DelCtx(ctx, key)
+	return err
+}
+
+// WriteHeader records code and the header, and writes them into the wrapped writer.
+func (w *captureWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records bs, and writes it into the wrapped writer.
+func (w *captureWriter) Write(bs []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body = append(w.body, bs...)
+	return w.ResponseWriter.Write(bs)
+}
+
+// Flush flushes the wrapped writer if it implements http.Flusher.
+func (w *captureWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *captureWriter) response() *IdempotentResponse {
+	header := w.header.Clone()
+	for _, key := range idempotencyExcludedHeaders {
+		header.Del(key)
+	}
+
+	return &IdempotentResponse{
+		Status: w.status,
+		Header: header,
+		Body:   w.body,
+	}
+}
+
+// hashIdempotency returns the hex sha256 of values, the values are length-prefixed to avoid ambiguity.
+func hashIdempotency(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		_, _ = io.WriteString(h, strconv.Itoa(len(v))+":"+v)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayIdempotent writes the stored response of key into w, it returns false if there is no stored response.
+// The request is rejected if hash differs from the hash of the stored request.
+func replayIdempotent(w http.ResponseWriter, r *http.Request, store IdempotencyStore, key, hash string) (
+	bool, error) {
+	ctx := r.Context()
+	resp, err := store.Get(ctx, key)
+	if err != nil || resp == nil {
+		return false, err
+	}
+	if len(resp.RequestHash) > 0 && resp.RequestHash != hash {
+		writeBaseResponse(ctx, w, http.StatusUnprocessableEntity,
+			errors.InvalidParams("the idempotency key is reused with a different request"), wantsXml(r))
+		return true, nil
+	}
+
+	header := w.Header()
+	for k, v := range resp.Header {
+		header[k] = v
+	}
+	header.Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	if _, err := w.Write(resp.Body); err != nil {
+		logx.WithContext(ctx).Errorf("replay idempotency key %s failed, error: %v", key, err)
+	}
+
+	return true, nil
+}
+
+func writeIdempotencyError(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+	logx.WithContext(ctx).Errorf("idempotency store failed, error: %v", err)
+	writeBaseResponse(ctx, w, http.StatusServiceUnavailable, errors.Unavailable(""), wantsXml(r))
+}
+
+func ttlSeconds(ttl time.Duration) int {
+	seconds := int(ttl / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+
+	return seconds
+}
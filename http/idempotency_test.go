@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stores/redis/redistest"
+	"github.com/zeromicro/x/provider"
+	"github.com/zeromicro/x/test"
+)
+
+type failedIdempotencyStore struct {
+	IdempotencyStore
+}
+
+func (s failedIdempotencyStore) Get(context.Context, string) (*IdempotentResponse, error) {
+	return nil, errors.New("store failed")
+}
+
+func idempotencyUser(r *http.Request) string {
+	return r.Header.Get("X-User")
+}
+
+func newIdempotentRequest(key, user string) *http.Request {
+	return newIdempotentRequestWithBody(key, user, "/orders", "")
+}
+
+func newIdempotentRequestWithBody(key, user, url, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if len(key) > 0 {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	r.Header.Set("X-User", user)
+	return r
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	for name, store := range map[string]IdempotencyStore{
+		"memory": NewMemoryIdempotencyStore(),
+		"redis":  NewRedisIdempotencyStore(redistest.CreateRedis(t)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			handler := IdempotencyMiddleware(store, WithIdempotencyIdentity(idempotencyUser))(
+				func(w http.ResponseWriter, r *http.Request) {
+					body, err := io.ReadAll(r.Body)
+					assert.NoError(t, err)
+					assert.Equal(t, "order", string(body))
+					calls++
+					w.Header().Set("X-Order", "1")
+					http.SetCookie(w, &http.Cookie{Name: "session", Value: "a"})
+					w.Header().Set("Traceparent", "00-trace-span-01")
+					w.WriteHeader(http.StatusCreated)
+					JsonBaseResponse(w, message{Name: "order"})
+				})
+
+			newRequest := func(key, user, url string) *http.Request {
+				return newIdempotentRequestWithBody(key, user, url, "order")
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, newRequest("key", "a", "/orders"))
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+			body := w.Body.String()
+
+			w = httptest.NewRecorder()
+			handler(w, newRequest("key", "a", "/orders"))
+			assert.Equal(t, 1, calls)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, "1", w.Header().Get("X-Order"))
+			assert.Empty(t, w.Header().Get("Set-Cookie"))
+			assert.Empty(t, w.Header().Get("Traceparent"))
+			assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+			assert.Equal(t, body, w.Body.String())
+
+			handler(httptest.NewRecorder(), newRequest("key", "b", "/orders"))
+			handler(httptest.NewRecorder(), newRequest("", "a", "/orders"))
+			handler(httptest.NewRecorder(), newRequest("key", "a", "/payments"))
+			assert.Equal(t, 4, calls)
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareConflict(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	var w *httptest.ResponseRecorder
+	handler := IdempotencyMiddleware(store, WithIdempotencyIdentity(idempotencyUser))(
+		func(_ http.ResponseWriter, r *http.Request) {
+			w = httptest.NewRecorder()
+			IdempotencyMiddleware(store, WithIdempotencyIdentity(idempotencyUser))(
+				func(http.ResponseWriter, *http.Request) {
+					t.Fatal("duplicate should not be handled")
+				})(w, newIdempotentRequest("key", "a"))
+		})
+	handler(httptest.NewRecorder(), newIdempotentRequest("key", "a"))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, `{"code":40900,"msg":"a request with the same idempotency key is in progress"}`,
+		w.Body.String())
+}
+
+func TestIdempotencyMiddlewareRetryable(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	var calls int
+	handler := IdempotencyMiddleware(store, WithIdempotencyIdentity(idempotencyUser))(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if calls == 2 {
+				panic("boom")
+			}
+			JsonBaseResponse(w, message{Name: "order"})
+		})
+
+	handler(httptest.NewRecorder(), newIdempotentRequest("key", "a"))
+	assert.Panics(t, func() {
+		handler(httptest.NewRecorder(), newIdempotentRequest("key", "a"))
+	})
+	w := httptest.NewRecorder()
+	handler(w, newIdempotentRequest("key", "a"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotencyMiddlewareMismatch(t *testing.T) {
+	var calls int
+	handler := IdempotencyMiddleware(NewMemoryIdempotencyStore(), WithIdempotencyIdentity(idempotencyUser))(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			JsonBaseResponse(w, message{Name: "order"})
+		})
+	handler(httptest.NewRecorder(), newIdempotentRequestWithBody("key", "a", "/orders", "a"))
+
+	for _, url := range []string{"/orders?id=1", "/orders"} {
+		w := httptest.NewRecorder()
+		handler(w, newIdempotentRequestWithBody("key", "a", url, "b"))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, `{"code":40000,"msg":"the idempotency key is reused with a different request"}`,
+			w.Body.String())
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyMiddlewareIdentity(t *testing.T) {
+	assert.Panics(t, func() {
+		IdempotencyMiddleware(NewMemoryIdempotencyStore())
+	})
+
+	handler := IdempotencyMiddleware(NewMemoryIdempotencyStore(), WithIdempotencyIdentity(idempotencyUser))(
+		func(http.ResponseWriter, *http.Request) {
+			t.Fatal("should not be handled")
+		})
+	w := httptest.NewRecorder()
+	handler(w, newIdempotentRequest("key", ""))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `{"code":40100,"msg":"caller identity is required"}`, w.Body.String())
+}
+
+func TestIdempotencyMiddlewareErrors(t *testing.T) {
+	handler := IdempotencyMiddleware(NewMemoryIdempotencyStore(), WithIdempotencyIdentity(idempotencyUser),
+		WithIdempotencyRequired())(
+		func(http.ResponseWriter, *http.Request) {
+			t.Fatal("should not be handled")
+		})
+	w := httptest.NewRecorder()
+	handler(w, newIdempotentRequest("", ""))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"code":40000,"msg":"Idempotency-Key header is required"}`, w.Body.String())
+
+	handler = IdempotencyMiddleware(failedIdempotencyStore{NewMemoryIdempotencyStore()},
+		WithIdempotencyIdentity(idempotencyUser))(
+		func(http.ResponseWriter, *http.Request) {
+			t.Fatal("should not be handled")
+		})
+	w = httptest.NewRecorder()
+	handler(w, newIdempotentRequest("key", "a"))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":50300`)
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	clock := test.NewFakeClock(time.Unix(0, 0))
+	SetClock(clock)
+	defer SetClock(provider.SystemClock)
+
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore()
+	ok, err := store.Reserve(ctx, "key", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = store.Reserve(ctx, "key", time.Minute)
+	assert.False(t, ok)
+
+	clock.Advance(time.Minute)
+	ok, _ = store.Reserve(ctx, "key", time.Minute)
+	assert.True(t, ok)
+
+	assert.NoError(t, store.Save(ctx, "key", &IdempotentResponse{Status: http.StatusOK}, time.Hour))
+	resp, err := store.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.Status)
+}
+
+func TestTTLSeconds(t *testing.T) {
+	assert.Equal(t, 1, ttlSeconds(time.Millisecond))
+	assert.Equal(t, 60, ttlSeconds(time.Minute))
+}
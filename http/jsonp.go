@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/x/errors"
+)
+
+const (
+	// JavascriptContentType represents the content type for javascript.
+	JavascriptContentType = "application/javascript; charset=utf-8"
+
+	jsonpCallbackKey     = "callback"
+	maxJsonpCallbackLen  = 128
+	contentTypeOptions   = "X-Content-Type-Options"
+	contentTypeNoSniff   = "nosniff"
+	jsonpCallbackComment = "/**/"
+)
+
+// jsonpCallbackRe only allows the dotted javascript identifiers, like jQuery123.cb_1.
+var jsonpCallbackRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// OkJsonp writes v into w with 200 OK as a JSONP response, see WriteJsonp.
+func OkJsonp(w http.ResponseWriter, r *http.Request, v any) {
+	WriteJsonp(w, r, http.StatusOK, v)
+}
+
+// OkJsonpCtx writes v into w with 200 OK as a JSONP response, see WriteJsonp.
+func OkJsonpCtx(ctx context.Context, w http.ResponseWriter, r *http.Request, v any) {
+	WriteJsonpCtx(ctx, w, r, http.StatusOK, v)
+}
+
+// WriteJsonp writes v as json wrapped in the callback from the query of r into w with code,
+// like /**/callback({"name":"anyone"});. v is written as plain json if the request has no callback,
+// and an errors.KindInvalidParams base response is written with http.StatusBadRequest
+// if the callback is not a valid javascript identifier.
+func WriteJsonp(w http.ResponseWriter, r *http.Request, code int, v any) {
+	if err := doWriteJsonp(w, r, code, v); err != nil {
+		logx.Error(err)
+	}
+}
+
+// WriteJsonpCtx writes v as json wrapped in the callback from the query of r into w with code,
+// see WriteJsonp.
+func WriteJsonpCtx(ctx context.Context, w http.ResponseWriter, r *http.Request, code int, v any) {
+	if err := doWriteJsonp(w, r, code, v); err != nil {
+		logx.WithContext(ctx).Error(err)
+	}
+}
+
+// JsonpBaseResponse writes v into w as JsonBaseResponse does, wrapped in the callback from the query of r.
+func JsonpBaseResponse(w http.ResponseWriter, r *http.Request, v any) {
+	JsonpBaseResponseCtx(r.Context(), w, r, v)
+}
+
+// JsonpBaseResponseCtx writes v into w as JsonBaseResponseCtx does, wrapped in the callback from the query of r.
+func JsonpBaseResponseCtx(ctx context.Context, w http.ResponseWriter, r *http.Request, v any) {
+	// the invalid callback is responded before preparing v, so that the metrics record the actual response
+	if callback := r.URL.Query().Get(jsonpCallbackKey); len(callback) > 0 && !validJsonpCallback(callback) {
+		logx.WithContext(ctx).Error(writeInvalidJsonpCallback(ctx, w, callback))
+		return
+	}
+
+	code, resp := prepareBaseResponse(ctx, w, v)
+	WriteJsonpCtx(ctx, w, r, code, jsonEnvelope(ctx, resp))
+	observeBaseResponse(w, code, resp.Code)
+}
+
+func doWriteJsonp(w http.ResponseWriter, r *http.Request, code int, v any) error {
+	callback := r.URL.Query().Get(jsonpCallbackKey)
+	if len(callback) == 0 {
+		httpx.WriteJsonCtx(r.Context(), w, code, v)
+		return nil
+	}
+
+	if !validJsonpCallback(callback) {
+		return writeInvalidJsonpCallback(r.Context(), w, callback)
+	}
+
+	bs, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return fmt.Errorf("marshal json failed, error: %w", err)
+	}
+
+	body := make([]byte, 0, len(jsonpCallbackComment)+len(callback)+len(bs)+3)
+	body = append(body, jsonpCallbackComment...)
+	body = append(body, callback...)
+	body = append(body, '(')
+	body = append(body, bs...)
+	body = append(body, ");"...)
+	w.Header().Set(contentTypeOptions, contentTypeNoSniff)
+
+	return writeBytes(w, code, JavascriptContentType, body)
+}
+
+// writeInvalidJsonpCallback writes an errors.KindInvalidParams base response with http.StatusBadRequest
+// into w, and returns the error describing callback.
+func writeInvalidJsonpCallback(ctx context.Context, w http.ResponseWriter, callback string) error {
+	writeBaseResponse(ctx, w, http.StatusBadRequest, errors.InvalidParams("invalid jsonp callback"), false)
+	return fmt.Errorf("invalid jsonp callback: %q", callback)
+}
+
+// validJsonpCallback reports whether callback is a dotted javascript identifier within the max length,
+// the json encoder escapes U+2028 and U+2029, so the body is safe to be evaluated as javascript.
+func validJsonpCallback(callback string) bool {
+	return len(callback) <= maxJsonpCallbackLen && jsonpCallbackRe.MatchString(callback)
+}
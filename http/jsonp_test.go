@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newJsonpRequest(callback string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/?callback="+url.QueryEscape(callback), http.NoBody)
+}
+
+func TestJsonpBaseResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	JsonpBaseResponse(w, newJsonpRequest("jQuery1.cb_$"), message{Name: "any\u2028one"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, JavascriptContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, `/**/jQuery1.cb_$({"code":0,"msg":"ok","data":{"name":"any\u2028one"}});`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	JsonpBaseResponseCtx(context.Background(), w, newJsonpRequest("cb"), errorx.New(1001, "test"))
+	assert.Equal(t, `/**/cb({"code":1001,"msg":"test"});`, w.Body.String())
+}
+
+func TestJsonpBaseResponseContext(t *testing.T) {
+	SetProductionMode(true)
+	defer SetProductionMode(false)
+
+	traceID, err := trace.TraceIDFromHex("0123456789abcdef0123456789abcdef")
+	assert.NoError(t, err)
+	r := newJsonpRequest("cb")
+	r = r.WithContext(trace.ContextWithSpanContext(r.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+	})))
+	w := httptest.NewRecorder()
+	JsonpBaseResponse(w, r, errors.New("dial tcp 10.0.0.1:3306"))
+	assert.Equal(t, `/**/cb({"code":-1,"msg":"internal error",`+
+		`"correlationId":"0123456789abcdef0123456789abcdef"});`, w.Body.String())
+}
+
+func TestJsonpBaseResponseInvalidCallback(t *testing.T) {
+	counter, _ := fakeMetrics(t)
+
+	var record ResponseRecord
+	handler := RecordMiddleware(func(r *http.Request, rec ResponseRecord) {
+		record = rec
+	})(func(w http.ResponseWriter, r *http.Request) {
+		JsonpBaseResponse(w, r, errorx.New(1001, "test"))
+	})
+	w := httptest.NewRecorder()
+	handler(w, newJsonpRequest("alert(1)//"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"code":40000,"msg":"invalid jsonp callback"}`, w.Body.String())
+	assert.Equal(t, 40000, record.Code)
+	assert.Equal(t, [][]string{{"", "400", "40000"}}, counter.labels)
+}
+
+func TestJsonpInvalidCallback(t *testing.T) {
+	for _, callback := range []string{
+		"alert(1)//",
+		"cb;alert(1)",
+		"1cb",
+		"cb.",
+		"a..b",
+		"<script>",
+		strings.Repeat("a", 129),
+	} {
+		w := httptest.NewRecorder()
+		OkJsonp(w, newJsonpRequest(callback), message{Name: "anyone"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, callback)
+		assert.Equal(t, `{"code":40000,"msg":"invalid jsonp callback"}`, w.Body.String())
+		assert.NotContains(t, w.Header().Get("Content-Type"), "javascript")
+	}
+}
+
+func TestWriteJsonp(t *testing.T) {
+	w := httptest.NewRecorder()
+	OkJsonpCtx(context.Background(), w, httptest.NewRequest(http.MethodGet, "/", http.NoBody),
+		message{Name: "anyone"})
+	assert.Equal(t, `{"name":"anyone"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteJsonpCtx(context.Background(), w, newJsonpRequest("cb"), http.StatusCreated, message{Name: "anyone"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `/**/cb({"name":"anyone"});`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteJsonp(w, newJsonpRequest("cb"), http.StatusOK, complex(0, 0))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
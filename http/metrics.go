@@ -0,0 +1,174 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/metric"
+)
+
+const (
+	metricsNamespace = "http_server"
+	metricsSubsystem = "base_response"
+	// otherLabel is the label value of the codes beyond the cardinality limit.
+	otherLabel          = "other"
+	defaultMaxCodeLabel = 64
+)
+
+var (
+	metricCodeTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "code_total",
+		Help:      "http server base responses count.",
+		Labels:    []string{"route", "status", "code"},
+	})
+	metricDuration = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "duration_ms",
+		Help:      "http server base responses duration(ms).",
+		Labels:    []string{"route", "status", "code"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 750, 1000},
+	})
+
+	metricsConf = newMetricsOptions()
+	metricsLock sync.RWMutex
+)
+
+type (
+	// MetricsOption represents an option for SetupMetrics.
+	MetricsOption func(*metricsOptions)
+
+	metricsOptions struct {
+		disabled     bool
+		withoutRoute bool
+		codes        map[int]struct{}
+		maxCodes     int
+		// seenCodes is guarded by seenLock, it's only written when a new code is seen.
+		seenCodes map[int]struct{}
+		seenLock  sync.RWMutex
+	}
+)
+
+// SetupMetrics configures the business code metrics, which are recorded whenever a base response is written,
+// with the labels route, status and code. The metrics are exported if go-zero prometheus is enabled.
+// The route label is set by RouteMiddleware. By default, the first 64 distinct codes are labeled,
+// and the others are labeled as other.
+func SetupMetrics(opts ...MetricsOption) {
+	o := newMetricsOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	metricsLock.Lock()
+	metricsConf = o
+	metricsLock.Unlock()
+}
+
+// WithMetricsDisabled is an option to disable the business code metrics.
+func WithMetricsDisabled() MetricsOption {
+	return func(o *metricsOptions) {
+		o.disabled = true
+	}
+}
+
+// WithoutRouteLabel is an option to leave the route label empty, to reduce the cardinality.
+func WithoutRouteLabel() MetricsOption {
+	return func(o *metricsOptions) {
+		o.withoutRoute = true
+	}
+}
+
+// WithCodeLabels is an option to only label the given codes, the others are labeled as other.
+func WithCodeLabels(codes ...int) MetricsOption {
+	return func(o *metricsOptions) {
+		o.codes = make(map[int]struct{}, len(codes))
+		for _, code := range codes {
+			o.codes[code] = struct{}{}
+		}
+	}
+}
+
+// WithMaxCodeLabels is an option to label the first n distinct codes, the others are labeled as other.
+// It's ignored if WithCodeLabels is set.
+func WithMaxCodeLabels(n int) MetricsOption {
+	return func(o *metricsOptions) {
+		o.maxCodes = n
+	}
+}
+
+func newMetricsOptions() *metricsOptions {
+	return &metricsOptions{
+		maxCodes:  defaultMaxCodeLabel,
+		seenCodes: make(map[int]struct{}),
+	}
+}
+
+// observeBaseResponse records the metrics of a base response written into w with status and code,
+// the duration is only recorded if w wraps a ResponseWriter.
+func observeBaseResponse(w http.ResponseWriter, status, code int) {
+	var route string
+	rw, ok := responseWriterOf(w)
+	if ok {
+		route = rw.Record().Route
+	}
+
+	labels, ok := metricLabels(route, status, code)
+	if !ok {
+		return
+	}
+
+	metricCodeTotal.Inc(labels...)
+	if rw != nil {
+		metricDuration.Observe(now().Sub(rw.Record().Start).Milliseconds(), labels...)
+	}
+}
+
+func metricLabels(route string, status, code int) ([]string, bool) {
+	metricsLock.RLock()
+	o := metricsConf
+	metricsLock.RUnlock()
+
+	if o.disabled {
+		return nil, false
+	}
+	if o.withoutRoute {
+		route = ""
+	}
+
+	return []string{route, strconv.Itoa(status), o.codeLabel(code)}, true
+}
+
+// codeLabel returns the label value of code.
+func (o *metricsOptions) codeLabel(code int) string {
+	if o.codes != nil {
+		if _, ok := o.codes[code]; !ok {
+			return otherLabel
+		}
+		return strconv.Itoa(code)
+	}
+
+	o.seenLock.RLock()
+	_, seen := o.seenCodes[code]
+	full := len(o.seenCodes) >= o.maxCodes
+	o.seenLock.RUnlock()
+	if seen {
+		return strconv.Itoa(code)
+	}
+	if full {
+		return otherLabel
+	}
+
+	o.seenLock.Lock()
+	defer o.seenLock.Unlock()
+	if _, ok := o.seenCodes[code]; !ok {
+		if len(o.seenCodes) >= o.maxCodes {
+			return otherLabel
+		}
+		o.seenCodes[code] = struct{}{}
+	}
+
+	return strconv.Itoa(code)
+}
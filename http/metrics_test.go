@@ -0,0 +1,75 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/metric"
+	errorx "github.com/zeromicro/x/errors"
+)
+
+type fakeCounterVec struct {
+	metric.CounterVec
+	labels [][]string
+}
+
+func (v *fakeCounterVec) Inc(labels ...string) {
+	v.labels = append(v.labels, labels)
+}
+
+type fakeHistogramVec struct {
+	metric.HistogramVec
+	labels [][]string
+}
+
+func (v *fakeHistogramVec) Observe(_ int64, labels ...string) {
+	v.labels = append(v.labels, labels)
+}
+
+func fakeMetrics(t *testing.T) (*fakeCounterVec, *fakeHistogramVec) {
+	counter, histogram := metricCodeTotal, metricDuration
+	t.Cleanup(func() {
+		metricCodeTotal, metricDuration = counter, histogram
+		SetupMetrics()
+	})
+
+	fakeCounter, fakeHistogram := &fakeCounterVec{}, &fakeHistogramVec{}
+	metricCodeTotal, metricDuration = fakeCounter, fakeHistogram
+	return fakeCounter, fakeHistogram
+}
+
+func TestBaseResponseMetrics(t *testing.T) {
+	counter, histogram := fakeMetrics(t)
+
+	handler := RouteMiddleware("/users/:id")(func(w http.ResponseWriter, r *http.Request) {
+		JsonBaseResponseCtx(r.Context(), w, errorx.New(1001, "test"))
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", http.NoBody))
+	XmlBaseResponse(httptest.NewRecorder(), message{Name: "anyone"})
+
+	assert.Equal(t, [][]string{{"/users/:id", "200", "1001"}, {"", "200", "0"}}, counter.labels)
+	assert.Equal(t, [][]string{{"/users/:id", "200", "1001"}}, histogram.labels)
+}
+
+func TestSetupMetrics(t *testing.T) {
+	counter, _ := fakeMetrics(t)
+
+	SetupMetrics(WithoutRouteLabel(), WithCodeLabels(1001))
+	observeBaseResponse(NewResponseWriter(httptest.NewRecorder()), http.StatusOK, 1001)
+	observeBaseResponse(nil, http.StatusBadRequest, 1002)
+	assert.Equal(t, [][]string{{"", "200", "1001"}, {"", "400", "other"}}, counter.labels)
+
+	counter.labels = nil
+	SetupMetrics(WithMaxCodeLabels(1))
+	observeBaseResponse(nil, http.StatusOK, 1)
+	observeBaseResponse(nil, http.StatusOK, 2)
+	observeBaseResponse(nil, http.StatusOK, 1)
+	assert.Equal(t, [][]string{{"", "200", "1"}, {"", "200", "other"}, {"", "200", "1"}}, counter.labels)
+
+	counter.labels = nil
+	SetupMetrics(WithMetricsDisabled())
+	observeBaseResponse(nil, http.StatusOK, 1)
+	assert.Empty(t, counter.labels)
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/x/internal/negotiate"
+)
+
+const (
+	acceptHeader       = "Accept"
+	jsonContentType    = "application/json"
+	xmlTextContentType = "text/xml"
+)
+
+// wantsXml reports whether the client of r prefers xml to json, according to the Accept header,
+// or the Content-Type header if the Accept header is absent. json is preferred on a tie.
+func wantsXml(r *http.Request) bool {
+	accept := r.Header.Get(acceptHeader)
+	if len(accept) == 0 {
+		return isXmlMediaType(r.Header.Get(httpx.ContentType))
+	}
+
+	var jsonQ, xmlQ float64
+	for _, part := range strings.Split(accept, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		q, ok := negotiate.ParseQuality(params)
+		if !ok {
+			continue
+		}
+
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case isXmlMediaType(mediaType):
+			xmlQ = maxFloat(xmlQ, q)
+		case mediaType == jsonContentType, strings.HasSuffix(mediaType, "+json"):
+			jsonQ = maxFloat(jsonQ, q)
+		}
+	}
+
+	return xmlQ > jsonQ
+}
+
+func isXmlMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == XmlContentType || mediaType == xmlTextContentType || strings.HasSuffix(mediaType, "+xml")
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
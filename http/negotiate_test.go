@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/x/test"
+)
+
+func TestWantsXml(t *testing.T) {
+	executor := test.NewExecutor[map[string]string, bool]()
+	executor.Add([]test.Data[map[string]string, bool]{
+		{
+			Name: "empty",
+		},
+		{
+			Name:  "json",
+			Input: map[string]string{acceptHeader: "application/json"},
+		},
+		{
+			Name:  "xml",
+			Input: map[string]string{acceptHeader: "application/xml"},
+			Want:  true,
+		},
+		{
+			Name:  "text-xml",
+			Input: map[string]string{acceptHeader: "text/html, text/xml;q=0.9"},
+			Want:  true,
+		},
+		{
+			Name:  "json-preferred",
+			Input: map[string]string{acceptHeader: "application/xml;q=0.5, application/json"},
+		},
+		{
+			Name:  "tie",
+			Input: map[string]string{acceptHeader: "application/xml, application/vnd.x.v2+json"},
+		},
+		{
+			Name:  "invalid",
+			Input: map[string]string{acceptHeader: "application/xml;q=x, ;"},
+		},
+		{
+			Name:  "content-type",
+			Input: map[string]string{httpx.ContentType: "application/soap+xml; charset=utf-8"},
+			Want:  true,
+		},
+	}...)
+	executor.Run(t, func(headers map[string]string) bool {
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return wantsXml(r)
+	})
+}
@@ -0,0 +1,324 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/x/errors"
+)
+
+const (
+	defaultPageKey   = "page"
+	defaultLimitKey  = "limit"
+	defaultCursorKey = "cursor"
+	defaultLimit     = 20
+	defaultMaxLimit  = 100
+	linkHeader       = "Link"
+	cursorSeparator  = "."
+	// minCursorKeyLen is the minimum length of the cursor signing keys, which is 128 bits.
+	minCursorKeyLen = 16
+)
+
+type (
+	// PageResponse is the data of the base response of an offset-paginated list.
+	PageResponse[T any] struct {
+		List  []T   `json:"list" xml:"list>item"`
+		Total int64 `json:"total" xml:"total"`
+		Page  int   `json:"page" xml:"page"`
+		Limit int   `json:"limit" xml:"limit"`
+	}
+
+	// CursorResponse is the data of the base response of a cursor-paginated list.
+	CursorResponse[T any] struct {
+		List       []T    `json:"list" xml:"list>item"`
+		NextCursor string `json:"nextCursor,omitempty" xml:"nextCursor,omitempty"`
+		PrevCursor string `json:"prevCursor,omitempty" xml:"prevCursor,omitempty"`
+		HasMore    bool   `json:"hasMore" xml:"hasMore"`
+	}
+
+	// Page represents the page and limit parsed from a request, Page starts from 1.
+	Page struct {
+		Page  int
+		Limit int
+	}
+
+	// PageOption represents an option for the pagination helpers.
+	PageOption func(*pageOptions)
+
+	// CursorCodec encodes and decodes the opaque cursors signed with HMAC-SHA256,
+	// so that the clients can't forge them.
+	CursorCodec struct {
+		key []byte
+	}
+
+	pageOptions struct {
+		pageKey      string
+		limitKey     string
+		cursorKey    string
+		defaultLimit int
+		maxLimit     int
+	}
+)
+
+// WithDefaultLimit is an option to set the limit used if the request doesn't specify one, defaults to 20.
+// It panics if limit is less than 1.
+func WithDefaultLimit(limit int) PageOption {
+	if limit < 1 {
+		panic("default limit must be positive")
+	}
+
+	return func(o *pageOptions) {
+		o.defaultLimit = limit
+	}
+}
+
+// WithMaxLimit is an option to cap the limit of the requests, defaults to 100.
+func WithMaxLimit(limit int) PageOption {
+	return func(o *pageOptions) {
+		o.maxLimit = limit
+	}
+}
+
+// WithPageKeys is an option to set the query keys of the page, the limit and the cursor,
+// the empty keys keep the defaults, which are page, limit and cursor.
+func WithPageKeys(page, limit, cursor string) PageOption {
+	return func(o *pageOptions) {
+		if len(page) > 0 {
+			o.pageKey = page
+		}
+		if len(limit) > 0 {
+			o.limitKey = limit
+		}
+		if len(cursor) > 0 {
+			o.cursorKey = cursor
+		}
+	}
+}
+
+// Offset returns the number of the items before the page, it's capped by math.MaxInt on overflows.
+func (p Page) Offset() int {
+	if p.Page < 2 || p.Limit < 1 {
+		return 0
+	}
+	if p.Page-1 > math.MaxInt/p.Limit {
+		return math.MaxInt
+	}
+
+	return (p.Page - 1) * p.Limit
+}
+
+// NewPageResponse creates a PageResponse of list in page.
+func NewPageResponse[T any](list []T, total int64, page Page) PageResponse[T] {
+	if list == nil {
+		list = []T{}
+	}
+
+	return PageResponse[T]{
+		List:  list,
+		Total: total,
+		Page:  page.Page,
+		Limit: page.Limit,
+	}
+}
+
+// ParsePage parses the page and the limit from the query of r, the limit is capped by the max limit.
+// It returns an errors.KindInvalidParams error if they're not positive integers,
+// or the offset of the page overflows.
+func ParsePage(r *http.Request, opts ...PageOption) (Page, error) {
+	o := newPageOptions(opts...)
+	query := r.URL.Query()
+
+	page, err := parsePositive(query.Get(o.pageKey), 1, o.pageKey)
+	if err != nil {
+		return Page{}, err
+	}
+
+	limit, err := parseLimit(query.Get(o.limitKey), o)
+	if err != nil {
+		return Page{}, err
+	}
+	if page-1 > math.MaxInt/limit {
+		return Page{}, errors.InvalidParams(o.pageKey + " is too large")
+	}
+
+	return Page{Page: page, Limit: limit}, nil
+}
+
+// ParseCursor parses the limit and the cursor from the query of r, the cursor is decoded into v by codec,
+// v is left untouched if the request has no cursor. The limit is capped by the max limit.
+// It returns an errors.KindInvalidParams error if the limit or the cursor is invalid.
+func ParseCursor(r *http.Request, codec *CursorCodec, v any, opts ...PageOption) (int, error) {
+	o := newPageOptions(opts...)
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get(o.limitKey), o)
+	if err != nil {
+		return 0, err
+	}
+
+	if cursor := query.Get(o.cursorKey); len(cursor) > 0 {
+		if err := codec.Decode(cursor, v); err != nil {
+			return 0, err
+		}
+	}
+
+	return limit, nil
+}
+
+// SetPageLinks sets the RFC 8288 Link header on w with the first, prev, next and last pages of r.
+func SetPageLinks(w http.ResponseWriter, r *http.Request, page Page, total int64, opts ...PageOption) {
+	o := newPageOptions(opts...)
+	last := 1
+	if page.Limit > 0 && total > 0 {
+		last = int((total + int64(page.Limit) - 1) / int64(page.Limit))
+	}
+
+	link := func(p int) string {
+		return pageURL(r, map[string]string{
+			o.pageKey:  strconv.Itoa(p),
+			o.limitKey: strconv.Itoa(page.Limit),
+		})
+	}
+
+	links := []string{formatLink(link(1), "first")}
+	if page.Page > 1 {
+		prev := page.Page - 1
+		if prev > last {
+			prev = last
+		}
+		links = append(links, formatLink(link(prev), "prev"))
+	}
+	if page.Page < last {
+		links = append(links, formatLink(link(page.Page+1), "next"))
+	}
+	links = append(links, formatLink(link(last), "last"))
+	w.Header().Set(linkHeader, strings.Join(links, ", "))
+}
+
+// SetCursorLinks sets the RFC 8288 Link header on w with the next and prev cursors of r,
+// the empty cursors are omitted.
+func SetCursorLinks(w http.ResponseWriter, r *http.Request, next, prev string, opts ...PageOption) {
+	o := newPageOptions(opts...)
+	var links []string
+	if len(prev) > 0 {
+		links = append(links, formatLink(pageURL(r, map[string]string{o.cursorKey: prev}), "prev"))
+	}
+	if len(next) > 0 {
+		links = append(links, formatLink(pageURL(r, map[string]string{o.cursorKey: next}), "next"))
+	}
+	if len(links) > 0 {
+		w.Header().Set(linkHeader, strings.Join(links, ", "))
+	}
+}
+
+// NewCursorCodec creates a CursorCodec signing the cursors with key,
+// it panics if key is shorter than 16 bytes, because the cursors signed with it are easy to forge.
+func NewCursorCodec(key []byte) *CursorCodec {
+	if len(key) < minCursorKeyLen {
+		panic("cursor key must be at least 16 bytes")
+	}
+
+	return &CursorCodec{key: key}
+}
+
+// Encode encodes v as json into an opaque signed cursor.
+func (c *CursorCodec) Encode(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + cursorSeparator + encoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode verifies cursor and decodes it into v,
+// it returns an errors.KindInvalidParams error if cursor is malformed or forged.
+func (c *CursorCodec) Decode(cursor string, v any) error {
+	encoded, sig, ok := strings.Cut(cursor, cursorSeparator)
+	if !ok {
+		return errors.InvalidParams("invalid cursor")
+	}
+
+	encoding := base64.RawURLEncoding
+	payload, err := encoding.DecodeString(encoded)
+	if err != nil {
+		return errors.InvalidParams("invalid cursor")
+	}
+	mac, err := encoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, c.sign(payload)) {
+		return errors.InvalidParams("invalid cursor")
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return errors.InvalidParams("invalid cursor")
+	}
+
+	return nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+func newPageOptions(opts ...PageOption) pageOptions {
+	o := pageOptions{
+		pageKey:      defaultPageKey,
+		limitKey:     defaultLimitKey,
+		cursorKey:    defaultCursorKey,
+		defaultLimit: defaultLimit,
+		maxLimit:     defaultMaxLimit,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func parseLimit(s string, o pageOptions) (int, error) {
+	limit, err := parsePositive(s, o.defaultLimit, o.limitKey)
+	if err != nil {
+		return 0, err
+	}
+	if o.maxLimit > 0 && limit > o.maxLimit {
+		limit = o.maxLimit
+	}
+
+	return limit, nil
+}
+
+func parsePositive(s string, defaultValue int, key string) (int, error) {
+	if len(s) == 0 {
+		return defaultValue, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 1 {
+		return 0, errors.InvalidParams(key + " must be a positive integer")
+	}
+
+	return v, nil
+}
+
+func pageURL(r *http.Request, params map[string]string) string {
+	query := r.URL.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+func formatLink(url, rel string) string {
+	return "<" + url + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/test"
+)
+
+type pageCursor struct {
+	ID int `json:"id" xml:"id"`
+}
+
+func TestParsePage(t *testing.T) {
+	type result struct {
+		Page Page
+		Err  bool
+	}
+
+	executor := test.NewExecutor[string, result]()
+	executor.Add([]test.Data[string, result]{
+		{Name: "default", Input: "/", Want: result{Page: Page{Page: 1, Limit: 20}}},
+		{Name: "specified", Input: "/?page=3&limit=10", Want: result{Page: Page{Page: 3, Limit: 10}}},
+		{Name: "capped", Input: "/?limit=1000", Want: result{Page: Page{Page: 1, Limit: 100}}},
+		{Name: "invalid-page", Input: "/?page=0", Want: result{Err: true}},
+		{Name: "invalid-limit", Input: "/?limit=abc", Want: result{Err: true}},
+		{Name: "overflow", Input: "/?page=" + strconv.Itoa(math.MaxInt), Want: result{Err: true}},
+	}...)
+	executor.Run(t, func(url string) result {
+		page, err := ParsePage(httptest.NewRequest(http.MethodGet, url, http.NoBody))
+		if err != nil {
+			assert.True(t, errorx.IsKind(err, errorx.KindInvalidParams))
+		}
+		return result{Page: page, Err: err != nil}
+	})
+
+	page, err := ParsePage(httptest.NewRequest(http.MethodGet, "/?p=2&size=80", http.NoBody),
+		WithPageKeys("p", "size", ""), WithDefaultLimit(5), WithMaxLimit(50))
+	assert.NoError(t, err)
+	assert.Equal(t, Page{Page: 2, Limit: 50}, page)
+	assert.Equal(t, 50, page.Offset())
+	assert.Equal(t, 0, Page{}.Offset())
+	assert.Equal(t, math.MaxInt, Page{Page: math.MaxInt, Limit: 2}.Offset())
+
+	assert.Panics(t, func() {
+		WithDefaultLimit(0)
+	})
+}
+
+func TestCursorCodec(t *testing.T) {
+	assert.Panics(t, func() {
+		NewCursorCodec(nil)
+	})
+	assert.Panics(t, func() {
+		NewCursorCodec([]byte("secret"))
+	})
+
+	codec := NewCursorCodec([]byte("0123456789abcdef"))
+	cursor, err := codec.Encode(pageCursor{ID: 10})
+	assert.NoError(t, err)
+
+	var c pageCursor
+	assert.NoError(t, codec.Decode(cursor, &c))
+	assert.Equal(t, pageCursor{ID: 10}, c)
+
+	forged, err := NewCursorCodec([]byte("fedcba9876543210")).Encode(pageCursor{ID: 11})
+	assert.NoError(t, err)
+	for _, cursor := range []string{forged, "abc", "!.abc", "e30.!", cursor + "x"} {
+		assert.True(t, errorx.IsKind(codec.Decode(cursor, &c), errorx.KindInvalidParams), cursor)
+	}
+
+	_, err = codec.Encode(complex(0, 0))
+	assert.Error(t, err)
+}
+
+func TestParseCursor(t *testing.T) {
+	codec := NewCursorCodec([]byte("0123456789abcdef"))
+	cursor, _ := codec.Encode(pageCursor{ID: 10})
+
+	var c pageCursor
+	limit, err := ParseCursor(httptest.NewRequest(http.MethodGet, "/?limit=5&cursor="+cursor, http.NoBody),
+		codec, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, limit)
+	assert.Equal(t, pageCursor{ID: 10}, c)
+
+	c = pageCursor{}
+	limit, err = ParseCursor(httptest.NewRequest(http.MethodGet, "/", http.NoBody), codec, &c)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, limit)
+	assert.Zero(t, c)
+
+	_, err = ParseCursor(httptest.NewRequest(http.MethodGet, "/?cursor=abc", http.NoBody), codec, &c)
+	assert.Error(t, err)
+	_, err = ParseCursor(httptest.NewRequest(http.MethodGet, "/?limit=-1", http.NoBody), codec, &c)
+	assert.Error(t, err)
+}
+
+func TestSetPageLinks(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/users?page=2&limit=10&q=a", http.NoBody)
+	SetPageLinks(w, r, Page{Page: 2, Limit: 10}, 35)
+	assert.Equal(t, `</users?limit=10&page=1&q=a>; rel="first", `+
+		`</users?limit=10&page=1&q=a>; rel="prev", `+
+		`</users?limit=10&page=3&q=a>; rel="next", `+
+		`</users?limit=10&page=4&q=a>; rel="last"`, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	SetPageLinks(w, r, Page{Page: 1, Limit: 10}, 0)
+	assert.Equal(t, `</users?limit=10&page=1&q=a>; rel="first", `+
+		`</users?limit=10&page=1&q=a>; rel="last"`, w.Header().Get("Link"))
+}
+
+func TestSetCursorLinks(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/users?cursor=a", http.NoBody)
+	SetCursorLinks(w, r, "b", "")
+	assert.Equal(t, `</users?cursor=b>; rel="next"`, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	SetCursorLinks(w, r, "", "")
+	assert.Empty(t, w.Header().Get("Link"))
+}
+
+func TestPageBaseResponse(t *testing.T) {
+	resp := NewPageResponse([]message{{Name: "anyone"}}, 1, Page{Page: 1, Limit: 10})
+	w := httptest.NewRecorder()
+	JsonBaseResponse(w, resp)
+	assert.Equal(t, `{"code":0,"msg":"ok","data":{"list":[{"name":"anyone"}],"total":1,"page":1,"limit":10}}`,
+		w.Body.String())
+
+	w = httptest.NewRecorder()
+	XmlBaseResponse(w, CursorResponse[pageCursor]{List: []pageCursor{{ID: 1}}, NextCursor: "b", HasMore: true})
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>0</code><msg>ok</msg><data><list>`+
+		`<item><id>1</id></item></list><nextCursor>b</nextCursor><hasMore>true</hasMore></data></xml>`,
+		w.Body.String())
+
+	w = httptest.NewRecorder()
+	JsonBaseResponse(w, NewPageResponse[message](nil, 0, Page{Page: 1, Limit: 10}))
+	assert.Equal(t, `{"code":0,"msg":"ok","data":{"list":[],"total":0,"page":1,"limit":10}}`, w.Body.String())
+}
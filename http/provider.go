@@ -0,0 +1,48 @@
+package http
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zeromicro/x/provider"
+)
+
+var (
+	clock       = provider.SystemClock
+	idGenerator = provider.UUIDGenerator
+	providerMu  sync.RWMutex
+)
+
+// SetClock sets the clock of this package, which drives the timestamps in response metadata,
+// the start and first-write times and durations recorded by ResponseWriter, the duration metrics,
+// and the expiry of MemoryIdempotencyStore.
+// It's useful to replace it with a fake one to keep the responses stable in tests,
+// note that a fake clock freezes the latencies and the expiry until it's advanced.
+func SetClock(c provider.Clock) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	clock = c
+}
+
+// SetIDGenerator sets the generator of this package, which drives the request IDs in response metadata,
+// the correlation IDs of the redacted errors, and the locations of the files saved by DiskUploadSink and MemoryUploadSink.
+// It's useful to replace it with a fake one to keep the responses stable in tests.
+func SetIDGenerator(g provider.IDGenerator) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	idGenerator = g
+}
+
+func now() time.Time {
+	providerMu.RLock()
+	c := clock
+	providerMu.RUnlock()
+	return c.Now()
+}
+
+func newID() string {
+	providerMu.RLock()
+	g := idGenerator
+	providerMu.RUnlock()
+	return g.NewID()
+}
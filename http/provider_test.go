@@ -0,0 +1,23 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/x/provider"
+	"github.com/zeromicro/x/test"
+)
+
+func TestSetClock(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(test.NewFakeClock(start))
+	defer SetClock(provider.SystemClock)
+	assert.Equal(t, start, now())
+}
+
+func TestSetIDGenerator(t *testing.T) {
+	SetIDGenerator(test.NewSequenceIDGenerator("req"))
+	defer SetIDGenerator(provider.UUIDGenerator)
+	assert.Equal(t, "req-1", newID())
+}
@@ -0,0 +1,303 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type (
+	// ResponseRecord is the data recorded by ResponseWriter.
+	ResponseRecord struct {
+		// Status represents the http status code, it's http.StatusOK if the header is written implicitly.
+		Status int
+		// Bytes represents the number of the body bytes written.
+		Bytes int64
+		// Start represents the time when the ResponseWriter is created.
+		Start time.Time
+		// FirstWrite represents the time of the first write of the header or body,
+		// it's zero if nothing is written.
+		FirstWrite time.Time
+		// Err represents the first write error, including the short writes.
+		Err error
+		// Code represents the business code of the written base response,
+		// it's only valid if HasCode is true.
+		Code    int
+		HasCode bool
+		// Route represents the route pattern set by RouteMiddleware, like /users/:id.
+		Route string
+	}
+
+	// ResponseWriter is an http.ResponseWriter wrapper which records the status code, bytes written,
+	// first-write time, write errors and the business code of the base response.
+	// It implements Unwrap for http.ResponseController, the writers returned by WithFeatures, and passed to
+	// the handlers by the middlewares of this package, implement http.Flusher, http.Hijacker and http.Pusher
+	// only if the wrapped writer does.
+	ResponseWriter struct {
+		http.ResponseWriter
+		lock   sync.Mutex
+		record ResponseRecord
+	}
+
+	// flusherFunc is an http.Flusher which calls itself on flushing.
+	flusherFunc func()
+
+	// hijackerFunc is an http.Hijacker which calls itself on hijacking.
+	hijackerFunc func() (net.Conn, *bufio.ReadWriter, error)
+
+	// RecordHandler is called with the request and the record after the handler returns.
+	RecordHandler func(r *http.Request, record ResponseRecord)
+)
+
+// NewResponseWriter wraps w into a ResponseWriter, the ResponseWriter is returned as is if w is already one.
+// Pass the result of WithFeatures to the handlers, so that they can still flush, hijack or push, for example:
+//
+//	rw := NewResponseWriter(w)
+//	next(rw.WithFeatures(), r)
+//	record := rw.Record()
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(interface{ responseWriter() *ResponseWriter }); ok {
+		return rw.responseWriter()
+	}
+
+	return &ResponseWriter{
+		ResponseWriter: w,
+		record: ResponseRecord{
+			Start: now(),
+		},
+	}
+}
+
+// Record returns a snapshot of the recorded data.
+func (w *ResponseWriter) Record() ResponseRecord {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.record
+}
+
+// WriteHeader records code and writes it into the wrapped writer, only the first call takes effect.
+func (w *ResponseWriter) WriteHeader(code int) {
+	w.lock.Lock()
+	if w.record.Status == 0 {
+		w.record.Status = code
+		w.markWrite()
+	}
+	w.lock.Unlock()
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes bs into the wrapped writer, and records the bytes written and the error.
+func (w *ResponseWriter) Write(bs []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(bs)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.record.Status == 0 {
+		w.record.Status = http.StatusOK
+	}
+	w.markWrite()
+	w.record.Bytes += int64(n)
+	if w.record.Err == nil {
+		if err != nil {
+			w.record.Err = err
+		} else if n < len(bs) {
+			w.record.Err = io.ErrShortWrite
+		}
+	}
+
+	return n, err
+}
+
+// Unwrap returns the wrapped writer.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *ResponseWriter) flush() {
+	w.lock.Lock()
+	if w.record.Status == 0 {
+		w.record.Status = http.StatusOK
+	}
+	w.markWrite()
+	w.lock.Unlock()
+
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *ResponseWriter) markWrite() {
+	if w.record.FirstWrite.IsZero() {
+		w.record.FirstWrite = now()
+	}
+}
+
+// setErr records err as the write error if it's the first one.
+func (w *ResponseWriter) setErr(err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.record.Err == nil {
+		w.record.Err = err
+	}
+}
+
+func (w *ResponseWriter) responseWriter() *ResponseWriter {
+	return w
+}
+
+func (w *ResponseWriter) setCode(code int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.record.Code = code
+	w.record.HasCode = true
+}
+
+// WithFeatures returns w with the optional interfaces of the wrapped writer, http.Flusher, http.Hijacker
+// and http.Pusher, so that the handlers can detect the features by type assertions as usual.
+// The flushes are recorded as writes.
+func (w *ResponseWriter) WithFeatures() http.ResponseWriter {
+	flusher, canFlush := w.ResponseWriter.(http.Flusher)
+	hijacker, canHijack := w.ResponseWriter.(http.Hijacker)
+	pusher, canPush := w.ResponseWriter.(http.Pusher)
+	if canFlush {
+		flusher = flusherFunc(w.flush)
+	}
+
+	switch {
+	case canFlush && canHijack && canPush:
+		return struct {
+			*ResponseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, flusher, hijacker, pusher}
+	case canFlush && canHijack:
+		return struct {
+			*ResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{w, flusher, hijacker}
+	case canFlush && canPush:
+		return struct {
+			*ResponseWriter
+			http.Flusher
+			http.Pusher
+		}{w, flusher, pusher}
+	case canHijack && canPush:
+		return struct {
+			*ResponseWriter
+			http.Hijacker
+			http.Pusher
+		}{w, hijacker, pusher}
+	case canFlush:
+		return struct {
+			*ResponseWriter
+			http.Flusher
+		}{w, flusher}
+	case canHijack:
+		return struct {
+			*ResponseWriter
+			http.Hijacker
+		}{w, hijacker}
+	case canPush:
+		return struct {
+			*ResponseWriter
+			http.Pusher
+		}{w, pusher}
+	default:
+		return w
+	}
+}
+
+// RecordMiddleware returns a middleware which wraps the response writer into a ResponseWriter,
+// and calls fn with the record after the handler returns, for example:
+//
+//	server.Use(RecordMiddleware(func(r *http.Request, record ResponseRecord) {
+//		latency.Observe(record.FirstWrite.Sub(record.Start).Milliseconds(), strconv.Itoa(record.Code))
+//	}))
+func RecordMiddleware(fn RecordHandler) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponseWriter(w)
+			next(rw.WithFeatures(), withResponseWriter(r, rw))
+			fn(r, rw.Record())
+		}
+	}
+}
+
+// AccessLogMiddleware returns a middleware which logs the requests with the recorded data,
+// including the business code of the base response.
+func AccessLogMiddleware() func(http.HandlerFunc) http.HandlerFunc {
+	return RecordMiddleware(logAccess)
+}
+
+func logAccess(r *http.Request, record ResponseRecord) {
+	fields := []logx.LogField{
+		logx.Field("method", r.Method),
+		logx.Field("path", r.URL.Path),
+		logx.Field("status", record.Status),
+		logx.Field("bytes", record.Bytes),
+		logx.Field("duration", now().Sub(record.Start).String()),
+	}
+	if record.HasCode {
+		fields = append(fields, logx.Field("code", record.Code))
+	}
+
+	logger := logx.WithContext(r.Context())
+	if record.Err != nil {
+		logger.Errorw(record.Err.Error(), fields...)
+	} else {
+		logger.Infow("access", fields...)
+	}
+}
+
+// RouteMiddleware returns a middleware which records route as the route pattern of the requests,
+// it's used as the route label of the business code metrics, for example:
+//
+//	rest.Route{Method: http.MethodGet, Path: "/users/:id", Handler: RouteMiddleware("/users/:id")(handler)}
+func RouteMiddleware(route string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponseWriter(w)
+			rw.lock.Lock()
+			rw.record.Route = route
+			rw.lock.Unlock()
+			next(rw.WithFeatures(), withResponseWriter(r, rw))
+		}
+	}
+}
+
+// recordCode records the business code into the ResponseWriter wrapped in w, if any.
+func recordCode(w http.ResponseWriter, code int) {
+	if rw, ok := responseWriterOf(w); ok {
+		rw.setCode(code)
+	}
+}
+
+// responseWriterOf returns the ResponseWriter wrapped in w, if any.
+func responseWriterOf(w http.ResponseWriter) (*ResponseWriter, bool) {
+	for w != nil {
+		switch rw := w.(type) {
+		case interface{ responseWriter() *ResponseWriter }:
+			return rw.responseWriter(), true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+func (f flusherFunc) Flush() {
+	f()
+}
+
+func (f hijackerFunc) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return f()
+}
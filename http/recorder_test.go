@@ -0,0 +1,145 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/provider"
+	"github.com/zeromicro/x/test"
+)
+
+type shortWriter struct {
+	http.ResponseWriter
+}
+
+func (w shortWriter) Write(bs []byte) (int, error) {
+	return len(bs) / 2, nil
+}
+
+type failedWriter struct {
+	http.ResponseWriter
+}
+
+func (w failedWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestResponseWriter(t *testing.T) {
+	clock := test.NewFakeClock(time.Unix(0, 0))
+	SetClock(clock)
+	defer SetClock(provider.SystemClock)
+
+	recorder := httptest.NewRecorder()
+	w := NewResponseWriter(recorder)
+	assert.Same(t, w, NewResponseWriter(w))
+	assert.Same(t, recorder, w.Unwrap())
+
+	clock.Advance(time.Second)
+	JsonBaseResponse(w, errorx.New(1001, "test"))
+	record := w.Record()
+	assert.Equal(t, http.StatusOK, record.Status)
+	assert.Equal(t, int64(recorder.Body.Len()), record.Bytes)
+	assert.Equal(t, time.Second, record.FirstWrite.Sub(record.Start))
+	assert.True(t, record.HasCode)
+	assert.Equal(t, 1001, record.Code)
+	assert.NoError(t, record.Err)
+
+	assert.Same(t, w, NewResponseWriter(w.WithFeatures()))
+}
+
+func TestResponseWriterFeatures(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	rw := NewResponseWriter(recorder)
+	w := rw.WithFeatures()
+	_, ok := w.(http.Hijacker)
+	assert.False(t, ok)
+	_, ok = w.(http.Pusher)
+	assert.False(t, ok)
+	flusher, ok := w.(http.Flusher)
+	assert.True(t, ok)
+	flusher.Flush()
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, http.StatusOK, rw.Record().Status)
+	assert.False(t, rw.Record().FirstWrite.IsZero())
+	actual, ok := responseWriterOf(w)
+	assert.True(t, ok)
+	assert.Same(t, rw, actual)
+
+	w = NewResponseWriter(shortWriter{recorder}).WithFeatures()
+	_, ok = w.(http.Flusher)
+	assert.False(t, ok)
+
+	w = NewResponseWriter(hijackWriter{recorder}).WithFeatures()
+	_, ok = w.(http.Hijacker)
+	assert.True(t, ok)
+	_, ok = w.(http.Flusher)
+	assert.True(t, ok)
+	_, ok = w.(http.Pusher)
+	assert.False(t, ok)
+}
+
+func TestResponseWriterImplicitStatus(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+	_, _ = w.Write([]byte("ok"))
+	w.WriteHeader(http.StatusNotFound)
+	assert.Equal(t, http.StatusOK, w.Record().Status)
+	assert.False(t, w.Record().HasCode)
+}
+
+func TestResponseWriterErrors(t *testing.T) {
+	w := NewResponseWriter(shortWriter{httptest.NewRecorder()})
+	OkXml(w, message{Name: "anyone"})
+	assert.Error(t, w.Record().Err)
+
+	w = NewResponseWriter(failedWriter{httptest.NewRecorder()})
+	OkHTML(w, "<p>anyone</p>")
+	assert.EqualError(t, w.Record().Err, "broken pipe")
+	assert.Zero(t, w.Record().Bytes)
+}
+
+func TestRecordMiddleware(t *testing.T) {
+	var record ResponseRecord
+	handler := RecordMiddleware(func(r *http.Request, rec ResponseRecord) {
+		record = rec
+	})(AccessLogMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		XmlBaseResponseCtx(r.Context(), w, errorx.New(1002, "test"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusOK, record.Status)
+	assert.Equal(t, 1002, record.Code)
+	assert.Equal(t, int64(w.Body.Len()), record.Bytes)
+}
+
+func TestRecordCode(t *testing.T) {
+	w := NewResponseWriter(httptest.NewRecorder())
+	recordCode(&unwrapWriter{w}, 1)
+	assert.Equal(t, 1, w.Record().Code)
+
+	recordCode(httptest.NewRecorder(), 1)
+	recordCode(nil, 1)
+}
+
+type hijackWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("not supported")
+}
+
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
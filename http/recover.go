@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/x/errors"
+)
+
+type (
+	// RecoverOption represents an option for RecoverMiddleware.
+	RecoverOption func(*recoverOptions)
+
+	recoverOptions struct {
+		err *errors.CodeMsg
+	}
+)
+
+// WithRecoverError is an option to set the CodeMsg responded on panics,
+// by default, it's BusinessCodeError with BusinessMsgInternalError.
+func WithRecoverError(cm *errors.CodeMsg) RecoverOption {
+	return func(o *recoverOptions) {
+		o.err = cm
+	}
+}
+
+// RecoverMiddleware returns a middleware which recovers the panics of the handlers,
+// logs the stacks, and responds the base response with http.StatusInternalServerError,
+// in xml or json according to the Accept header of the request.
+// If the handler has written the header or body before panicking, the base response is skipped
+// to avoid corrupting the response, and the panic is only logged.
+// http.ErrAbortHandler is re-panicked to abort the response as net/http does.
+// It can be used as a rest.Middleware, for example:
+//
+//	server.Use(RecoverMiddleware())
+func RecoverMiddleware(opts ...RecoverOption) func(http.HandlerFunc) http.HandlerFunc {
+	o := recoverOptions{
+		err: &errors.CodeMsg{Code: BusinessCodeError, Msg: BusinessMsgInternalError},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rw, ok := responseWriterOf(w)
+			if !ok {
+				rw = NewResponseWriter(w)
+				w = rw.WithFeatures()
+			}
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				ctx := r.Context()
+				logx.WithContext(ctx).Errorf("%s %s: panic: %+v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+				// the response is partially written, writing the base response would corrupt it
+				if record := rw.Record(); !record.FirstWrite.IsZero() {
+					logx.WithContext(ctx).Errorf("%s %s: response already written with status %d, "+
+						"the base response is skipped", r.Method, r.URL.Path, record.Status)
+					return
+				}
+
+				writeBaseResponse(ctx, w, http.StatusInternalServerError, o.err, wantsXml(r))
+			}()
+
+			next(w, r)
+		}
+	}
+}
+
+// writeBaseResponse writes v as a base response into w with code, in xml if asXml is true, otherwise in json.
+func writeBaseResponse(ctx context.Context, w http.ResponseWriter, code int, v any, asXml bool) {
+	_, resp := prepareBaseResponse(ctx, w, v)
+	if asXml {
+		writeXmlEnvelope(ctx, w, code, resp)
+	} else {
+		writeJsonEnvelope(ctx, w, code, resp)
+	}
+	observeBaseResponse(w, code, resp.Code)
+}
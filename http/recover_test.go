@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	handler := RecoverMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"code":-1,"msg":"internal error"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set(acceptHeader, XmlContentType)
+	handler(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, XmlContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>-1</code><msg>internal error</msg></xml>`,
+		w.Body.String())
+}
+
+func TestRecoverMiddlewareWithError(t *testing.T) {
+	handler := RecoverMiddleware(WithRecoverError(&errorx.CodeMsg{Code: 500, Msg: "server busy"}))(
+		func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"code":500,"msg":"server busy"}`, w.Body.String())
+}
+
+func TestRecoverMiddlewareAbort(t *testing.T) {
+	handler := RecoverMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	})
+}
+
+func TestRecoverMiddlewareNoPanic(t *testing.T) {
+	handler := RecoverMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		JsonBaseResponse(w, message{Name: "anyone"})
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"code":0,"msg":"ok","data":{"name":"anyone"}}`, w.Body.String())
+}
+
+func TestRecoverMiddlewareWritten(t *testing.T) {
+	handler := RecoverMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+
+	// the recorder installed by the outer middlewares is reused
+	var record ResponseRecord
+	handler = RecordMiddleware(func(r *http.Request, rec ResponseRecord) {
+		record = rec
+	})(RecoverMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, http.StatusInternalServerError, record.Status)
+	assert.True(t, record.HasCode)
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	productionMode bool
+	includeCaller  bool
+	safeErrors     []func(error) bool
+	redactLock     sync.RWMutex
+)
+
+// SetProductionMode enables or disables the production mode. In production mode, the messages of
+// the errors which are neither errors.CodeMsg nor gRPC status carrying business codes are redacted
+// from the base responses, unless they are allowed by AllowError or AllowErrorType.
+// A redacted error is rendered as BusinessCodeError, or the gRPC code of a status,
+// with BusinessMsgInternalError and a correlation ID, and logged in full.
+func SetProductionMode(enabled bool) {
+	redactLock.Lock()
+	defer redactLock.Unlock()
+	productionMode = enabled
+}
+
+// SetIncludeCaller enables or disables including the location where an errors.CodeMsg is created
+// in the base responses, it's useful in development, and it's ignored in production mode.
+func SetIncludeCaller(enabled bool) {
+	redactLock.Lock()
+	defer redactLock.Unlock()
+	includeCaller = enabled
+}
+
+// AllowError allows the messages of the errors matching target with errors.Is to be exposed in production mode.
+func AllowError(target error) {
+	addSafeError(func(err error) bool {
+		return errors.Is(err, target)
+	})
+}
+
+// AllowErrorType allows the messages of the errors matching type E with errors.As to be exposed
+// in production mode, generic type E is the error type.
+func AllowErrorType[E error]() {
+	addSafeError(func(err error) bool {
+		var target E
+		return errors.As(err, &target)
+	})
+}
+
+func addSafeError(fn func(error) bool) {
+	redactLock.Lock()
+	defer redactLock.Unlock()
+	safeErrors = append(safeErrors, fn)
+}
+
+func shouldIncludeCaller() bool {
+	redactLock.RLock()
+	defer redactLock.RUnlock()
+	return includeCaller && !productionMode
+}
+
+func shouldRedact(err error) bool {
+	redactLock.RLock()
+	defer redactLock.RUnlock()
+	if !productionMode {
+		return false
+	}
+
+	for _, fn := range safeErrors {
+		if fn(err) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// redactError returns a base response with the correlation ID id instead of the message of the error.
+func redactError(code int, id string) BaseResponse[any] {
+	return BaseResponse[any]{
+		Code:          code,
+		Msg:           BusinessMsgInternalError,
+		CorrelationID: id,
+	}
+}
+
+// logRedactedError logs err which is neither an errors.CodeMsg nor a gRPC status carrying a business code
+// in full with the correlation ID id.
+func logRedactedError(ctx context.Context, err error, id string) {
+	logx.WithContext(ctx).Errorw(fmt.Sprintf("%+v", err), logx.Field(correlationIDKey, id))
+}
+
+// correlationID returns the trace ID in ctx if present, otherwise a new ID.
+func correlationID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	return newID()
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/provider"
+	"github.com/zeromicro/x/test"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestProductionMode(t *testing.T) {
+	SetProductionMode(true)
+	SetIDGenerator(test.NewSequenceIDGenerator("ref"))
+	AllowError(sql.ErrNoRows)
+	AllowErrorType[*fs.PathError]()
+	defer func() {
+		SetProductionMode(false)
+		SetIDGenerator(provider.UUIDGenerator)
+		safeErrors = nil
+	}()
+
+	executor := test.NewExecutor[any, testWriterResult](comparisonOption)
+	executor.Add([]test.Data[any, testWriterResult]{
+		{
+			Name:  "error",
+			Input: errors.New("dial tcp 10.0.0.1:3306: connect: connection refused"),
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":-1,"msg":"internal error","correlationId":"ref-1"}`,
+			},
+		},
+		{
+			Name:  "allowed-error",
+			Input: fmt.Errorf("query: %w", sql.ErrNoRows),
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":-1,"msg":"query: sql: no rows in result set"}`,
+			},
+		},
+		{
+			Name:  "allowed-error-type",
+			Input: &fs.PathError{Op: "open", Path: "a.txt", Err: fs.ErrNotExist},
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":-1,"msg":"open a.txt: file does not exist"}`,
+			},
+		},
+		{
+			Name:  "code-msg",
+			Input: errorx.New(1, "test"),
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":1,"msg":"test"}`,
+			},
+		},
+		{
+			Name:  "status",
+			Input: status.Error(codes.Unavailable, "connection error: dial tcp 10.0.0.5:9000: refused"),
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":14,"msg":"internal error","correlationId":"ref-2"}`,
+			},
+		},
+		{
+			Name:  "status-with-business-code",
+			Input: errorx.New(1001, "user not found").(*errorx.CodeMsg).GRPCStatus().Err(),
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":1001,"msg":"user not found"}`,
+			},
+		},
+		{
+			Name:  "struct",
+			Input: message{Name: "anyone"},
+			Want: testWriterResult{
+				code:        200,
+				writeString: `{"code":0,"msg":"ok","data":{"name":"anyone"}}`,
+			},
+		},
+	}...)
+	executor.RunE(t, func(a any) (testWriterResult, error) {
+		w := &tracedResponseWriter{headers: make(map[string][]string)}
+		JsonBaseResponseCtx(context.Background(), w, a)
+		return w.result()
+	})
+}
+
+func TestProductionModeXml(t *testing.T) {
+	SetProductionMode(true)
+	SetIDGenerator(test.NewSequenceIDGenerator("ref"))
+	defer func() {
+		SetProductionMode(false)
+		SetIDGenerator(provider.UUIDGenerator)
+	}()
+
+	w := &tracedResponseWriter{headers: make(map[string][]string)}
+	XmlBaseResponse(w, errors.New("dial tcp 10.0.0.1:3306"))
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>-1</code><msg>internal error</msg>`+
+		`<correlationId>ref-1</correlationId></xml>`, w.builder.String())
+}
+
+func TestCorrelationID(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("0123456789abcdef0123456789abcdef")
+	assert.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+	}))
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", correlationID(ctx))
+	assert.NotEmpty(t, correlationID(context.Background()))
+}
+
+func TestSetIncludeCaller(t *testing.T) {
+	SetIncludeCaller(true)
+	defer SetIncludeCaller(false)
+
+	w := &tracedResponseWriter{headers: make(map[string][]string)}
+	JsonBaseResponse(w, errorx.New(1, "test"))
+	assert.Regexp(t, `^\{"code":1,"msg":"test","caller":".+redact_test.go:\d+"\}$`, w.builder.String())
+
+	SetProductionMode(true)
+	defer SetProductionMode(false)
+	w = &tracedResponseWriter{headers: make(map[string][]string)}
+	JsonBaseResponse(w, errorx.New(1, "test"))
+	assert.Equal(t, `{"code":1,"msg":"test"}`, w.builder.String())
+}
@@ -40,20 +40,7 @@ func doWriteXml(w http.ResponseWriter, code int, v any) error {
 		return fmt.Errorf("marshal xml failed, error: %w", err)
 	}
 
-	w.Header().Set(httpx.ContentType, XmlContentType)
-	w.WriteHeader(code)
-
-	if n, err := w.Write(bs); err != nil {
-		// http.ErrHandlerTimeout has been handled by http.TimeoutHandler,
-		// so it's ignored here.
-		if err != http.ErrHandlerTimeout {
-			return fmt.Errorf("write response failed, error: %w", err)
-		}
-	} else if n < len(bs) {
-		return fmt.Errorf("actual bytes: %d, written bytes: %d", len(bs), n)
-	}
-
-	return nil
+	return writeBytes(w, code, XmlContentType, bs)
 }
 
 // OkHTML writes v into w with 200 OK.
@@ -81,11 +68,14 @@ func WriteHTMLCtx(ctx context.Context, w http.ResponseWriter, code int, v string
 }
 
 func doWriteHTML(w http.ResponseWriter, code int, v string) error {
+	return writeBytes(w, code, HTMLContentType, []byte(v))
+}
 
-	w.Header().Set(httpx.ContentType, HTMLContentType)
+// writeBytes writes bs into w with code and contentType.
+func writeBytes(w http.ResponseWriter, code int, contentType string, bs []byte) error {
+	w.Header().Set(httpx.ContentType, contentType)
 	w.WriteHeader(code)
 
-	bs := []byte(v)
 	if n, err := w.Write(bs); err != nil {
 		// http.ErrHandlerTimeout has been handled by http.TimeoutHandler,
 		// so it's ignored here.
@@ -96,5 +86,27 @@ func doWriteHTML(w http.ResponseWriter, code int, v string) error {
 		return fmt.Errorf("actual bytes: %d, written bytes: %d", len(bs), n)
 	}
 
+	// the whole body is written, so the buffered writers can write it at once to report the errors.
+	if err := finishWrite(w); err != nil {
+		return fmt.Errorf("write response failed, error: %w", err)
+	}
+
+	return nil
+}
+
+// finishWrite writes the body buffered by the compressWriter wrapped in w, if any,
+// and returns the error of writing the response.
+func finishWrite(w http.ResponseWriter) error {
+	for w != nil {
+		switch cw := w.(type) {
+		case interface{ finish() error }:
+			return cw.finish()
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = cw.Unwrap()
+		default:
+			return nil
+		}
+	}
+
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/x/test"
 )
 
 type message struct {
@@ -246,3 +247,22 @@ func TestWriteHTMLLessWritten(t *testing.T) {
 	WriteHTML(&w, http.StatusOK, msg)
 	assert.Equal(t, http.StatusOK, w.code)
 }
+
+func BenchmarkWriteXml(b *testing.B) {
+	executor := test.NewExecutor[any, any]()
+	executor.Add([]test.Data[any, any]{
+		{
+			Name:  "struct",
+			Input: message{Name: "anyone"},
+		},
+		{
+			Name:  "base-response",
+			Input: wrapXmlBaseResponse(message{Name: "anyone"}),
+		},
+	}...)
+	executor.Bench(b, func(v any) any {
+		w := tracedResponseWriter{headers: make(map[string][]string)}
+		WriteXml(&w, http.StatusOK, v)
+		return nil
+	})
+}
@@ -0,0 +1,452 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/x/errors"
+)
+
+const (
+	defaultMaxFileSize   = 10 << 20
+	defaultMaxUploadSize = 32 << 20
+	defaultMaxFiles      = 16
+	// sniffLen is the number of bytes http.DetectContentType considers.
+	sniffLen = 512
+)
+
+// uploadExtensions are the file extensions of the sniffed content types, the types which browsers may render
+// as active content, like text/html and text/xml, are absent, so that their files are saved without extensions.
+var uploadExtensions = map[string]string{
+	"image/png":          ".png",
+	"image/jpeg":         ".jpg",
+	"image/gif":          ".gif",
+	"image/webp":         ".webp",
+	"image/bmp":          ".bmp",
+	"image/x-icon":       ".ico",
+	"audio/mpeg":         ".mp3",
+	"audio/wave":         ".wav",
+	"application/ogg":    ".ogg",
+	"video/mp4":          ".mp4",
+	"video/webm":         ".webm",
+	"video/avi":          ".avi",
+	"font/woff":          ".woff",
+	"font/woff2":         ".woff2",
+	"font/ttf":           ".ttf",
+	"font/otf":           ".otf",
+	"application/pdf":    ".pdf",
+	"application/zip":    ".zip",
+	"application/x-gzip": ".gz",
+	"application/wasm":   ".wasm",
+	"text/plain":         ".txt",
+}
+
+type (
+	// UploadedFile describes a file saved by ParseUpload.
+	UploadedFile struct {
+		// Field represents the form field name of the file.
+		Field string `json:"field" xml:"field"`
+		// Filename represents the file name given by the client, it's not sanitized.
+		Filename string `json:"filename" xml:"filename"`
+		// ContentType represents the content type sniffed from the content, not the one given by the client.
+		ContentType string `json:"contentType" xml:"contentType"`
+		// Size represents the size of the file in bytes.
+		Size int64 `json:"size" xml:"size"`
+		// Checksum represents the hex encoded checksum of the content, sha256 by default.
+		Checksum string `json:"checksum" xml:"checksum"`
+		// Location represents where the file is saved, returned by UploadSink.Save.
+		Location string `json:"location" xml:"location"`
+	}
+
+	// UploadResult is the result of ParseUpload.
+	UploadResult struct {
+		// Files represents the saved files in the order of the parts.
+		Files []UploadedFile
+		// Values represents the non-file form fields.
+		Values url.Values
+	}
+
+	// UploadSink stores the uploaded files.
+	UploadSink interface {
+		// Save stores the content of file read from r and returns its location,
+		// Size, Checksum and Location of file are not set yet.
+		// r returns an error if the content exceeds the limits, the partial content should be discarded then.
+		Save(ctx context.Context, file *UploadedFile, r io.Reader) (string, error)
+		// Remove removes the file at location, it's called to clean up the saved files if the upload fails.
+		Remove(ctx context.Context, location string) error
+	}
+
+	// UploadOption represents an option for ParseUpload.
+	UploadOption func(*uploadOptions)
+
+	// DiskUploadSink is an UploadSink saving the files into a directory with generated names,
+	// the locations are the file paths.
+	DiskUploadSink struct {
+		dir string
+	}
+
+	// MemoryUploadSink is an in-memory UploadSink, it's usually used in tests.
+	MemoryUploadSink struct {
+		lock  sync.Mutex
+		files map[string][]byte
+	}
+
+	uploadOptions struct {
+		maxFileSize   int64
+		maxUploadSize int64
+		maxFiles      int
+		allowedTypes  []string
+		newHash       func() hash.Hash
+	}
+
+	// uploadLimitReader reads a part, it fails if the part or the whole request exceeds the limits.
+	uploadLimitReader struct {
+		r         io.Reader
+		fileLeft  int64
+		totalLeft *int64
+		err       error
+		fileErr   func() error
+		totalErr  func() error
+	}
+
+	countingWriter struct {
+		w io.Writer
+		n int64
+	}
+)
+
+// WithMaxFileSize is an option to set the maximum size of each file in bytes, defaults to 10 MB.
+func WithMaxFileSize(size int64) UploadOption {
+	return func(o *uploadOptions) {
+		o.maxFileSize = size
+	}
+}
+
+// WithMaxUploadSize is an option to set the maximum total size of the files and the form values in bytes,
+// defaults to 32 MB.
+func WithMaxUploadSize(size int64) UploadOption {
+	return func(o *uploadOptions) {
+		o.maxUploadSize = size
+	}
+}
+
+// WithMaxFiles is an option to set the maximum number of the files, defaults to 16.
+func WithMaxFiles(n int) UploadOption {
+	return func(o *uploadOptions) {
+		o.maxFiles = n
+	}
+}
+
+// WithAllowedTypes is an option to set the allowed content types sniffed from the files,
+// the wildcard subtypes like image/* are supported. By default, all types are allowed.
+func WithAllowedTypes(types ...string) UploadOption {
+	return func(o *uploadOptions) {
+		o.allowedTypes = append(o.allowedTypes, types...)
+	}
+}
+
+// WithChecksum is an option to set the hash computing the checksums of the files, defaults to sha256.
+func WithChecksum(newHash func() hash.Hash) UploadOption {
+	return func(o *uploadOptions) {
+		o.newHash = newHash
+	}
+}
+
+// ParseUpload parses the multipart/form-data request r, streams the files into sink,
+// and returns the saved files with the form values.
+// The content types of the files are sniffed from their first 512 bytes.
+// It returns an errors.KindInvalidParams CodeMsg if r is not a valid multipart form,
+// an errors.KindPayloadTooLarge one if the limits are exceeded, an errors.KindUnsupportedMediaType one
+// if a file type is not allowed, and an errors.KindInternal one if sink fails,
+// so that the errors can be written by the base response writers, for example:
+//
+//	result, err := ParseUpload(r, sink, WithAllowedTypes("image/*"))
+//	if err != nil {
+//		JsonBaseResponseCtx(r.Context(), w, err)
+//		return
+//	}
+//
+// The saved files are removed from sink on failures.
+func ParseUpload(r *http.Request, sink UploadSink, opts ...UploadOption) (*UploadResult, error) {
+	o := uploadOptions{
+		maxFileSize:   defaultMaxFileSize,
+		maxUploadSize: defaultMaxUploadSize,
+		maxFiles:      defaultMaxFiles,
+		newHash:       sha256.New,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ctx := r.Context()
+	result := &UploadResult{Values: make(url.Values)}
+	if err := doParseUpload(ctx, r, sink, o, result); err != nil {
+		for _, file := range result.Files {
+			if rerr := sink.Remove(ctx, file.Location); rerr != nil {
+				logx.WithContext(ctx).Errorf("remove uploaded file %s failed, error: %v", file.Location, rerr)
+			}
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func doParseUpload(ctx context.Context, r *http.Request, sink UploadSink, o uploadOptions,
+	result *UploadResult) error {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return errors.InvalidParams(fmt.Sprintf("invalid multipart form: %v", err))
+	}
+
+	totalLeft := o.maxUploadSize
+	totalErr := func() error {
+		return errors.PayloadTooLarge(fmt.Sprintf("request exceeds the limit of %d bytes", o.maxUploadSize))
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.InvalidParams(fmt.Sprintf("invalid multipart form: %v", err))
+		}
+
+		field, filename := part.FormName(), part.FileName()
+		if len(filename) == 0 {
+			lr := &uploadLimitReader{
+				r:         part,
+				fileLeft:  totalLeft,
+				totalLeft: &totalLeft,
+				fileErr:   totalErr,
+				totalErr:  totalErr,
+			}
+			value, err := io.ReadAll(lr)
+			if err != nil {
+				return uploadReadError(lr, err)
+			}
+			result.Values.Add(field, string(value))
+			continue
+		}
+
+		if len(result.Files) >= o.maxFiles {
+			return errors.PayloadTooLarge(fmt.Sprintf("too many files, the limit is %d", o.maxFiles))
+		}
+
+		file, err := saveUploadedFile(ctx, part, sink, o, &totalLeft, totalErr)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, *file)
+	}
+}
+
+func saveUploadedFile(ctx context.Context, part *multipart.Part, sink UploadSink, o uploadOptions,
+	totalLeft *int64, totalErr func() error) (*UploadedFile, error) {
+	file := &UploadedFile{
+		Field:    part.FormName(),
+		Filename: part.FileName(),
+	}
+	lr := &uploadLimitReader{
+		r:         part,
+		fileLeft:  o.maxFileSize,
+		totalLeft: totalLeft,
+		fileErr: func() error {
+			return errors.PayloadTooLarge(fmt.Sprintf("file %s exceeds the limit of %d bytes",
+				file.Filename, o.maxFileSize))
+		},
+		totalErr: totalErr,
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(lr, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, uploadReadError(lr, err)
+	}
+	head = head[:n]
+	file.ContentType = http.DetectContentType(head)
+	if !typeAllowed(file.ContentType, o.allowedTypes) {
+		return nil, errors.UnsupportedMediaType(fmt.Sprintf("file %s of type %s is not allowed",
+			file.Filename, file.ContentType))
+	}
+
+	h := o.newHash()
+	counter := &countingWriter{w: h}
+	location, err := sink.Save(ctx, file, io.TeeReader(io.MultiReader(bytes.NewReader(head), lr), counter))
+	if lr.err != nil {
+		// the sink may have saved the partial content if it ignores the read errors
+		if err == nil {
+			if rerr := sink.Remove(ctx, location); rerr != nil {
+				logx.WithContext(ctx).Errorf("remove uploaded file %s failed, error: %v", location, rerr)
+			}
+		}
+		return nil, lr.err
+	}
+	if err != nil {
+		return nil, errors.Internal(fmt.Sprintf("save uploaded file %s failed, error: %v", file.Filename, err))
+	}
+
+	file.Size = counter.n
+	file.Checksum = hex.EncodeToString(h.Sum(nil))
+	file.Location = location
+	return file, nil
+}
+
+// uploadReadError returns the limit error of lr if any, otherwise, an errors.KindInvalidParams CodeMsg of err.
+func uploadReadError(lr *uploadLimitReader, err error) error {
+	if lr.err != nil {
+		return lr.err
+	}
+
+	return errors.InvalidParams(fmt.Sprintf("read multipart form failed: %v", err))
+}
+
+func typeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	for _, t := range allowed {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+		if prefix := strings.TrimSuffix(t, "*"); len(prefix) < len(t) &&
+			strings.HasPrefix(strings.ToLower(mediaType), strings.ToLower(prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *uploadLimitReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	n, err := l.r.Read(p)
+	l.fileLeft -= int64(n)
+	*l.totalLeft -= int64(n)
+	switch {
+	case *l.totalLeft < 0:
+		l.err = l.totalErr()
+	case l.fileLeft < 0:
+		l.err = l.fileErr()
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	return n, err
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewDiskUploadSink returns a DiskUploadSink saving the files into dir, dir is created if absent.
+func NewDiskUploadSink(dir string) *DiskUploadSink {
+	return &DiskUploadSink{dir: dir}
+}
+
+// Save implements UploadSink, the file is named with a generated ID and the extension of the sniffed
+// content type of file, the extension given by the client is ignored.
+func (s *DiskUploadSink) Save(_ context.Context, file *UploadedFile, r io.Reader) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	name := newID()
+	if mediaType, _, err := mime.ParseMediaType(file.ContentType); err == nil {
+		name += uploadExtensions[mediaType]
+	}
+	path := filepath.Join(s.dir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Remove implements UploadSink.
+func (s *DiskUploadSink) Remove(_ context.Context, location string) error {
+	err := os.Remove(location)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// NewMemoryUploadSink returns a MemoryUploadSink.
+func NewMemoryUploadSink() *MemoryUploadSink {
+	return &MemoryUploadSink{
+		files: make(map[string][]byte),
+	}
+}
+
+// Get returns the content of the file at location.
+func (s *MemoryUploadSink) Get(location string) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	content, ok := s.files[location]
+	return content, ok
+}
+
+// Len returns the number of the stored files.
+func (s *MemoryUploadSink) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.files)
+}
+
+// Save implements UploadSink, the location is a generated ID.
+func (s *MemoryUploadSink) Save(_ context.Context, _ *UploadedFile, r io.Reader) (string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	location := newID()
+	s.lock.Lock()
+	s.files[location] = content
+	s.lock.Unlock()
+	return location, nil
+}
+
+// Remove implements UploadSink.
+func (s *MemoryUploadSink) Remove(_ context.Context, location string) error {
+	s.lock.Lock()
+	delete(s.files, location)
+	s.lock.Unlock()
+	return nil
+}
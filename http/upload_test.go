@@ -0,0 +1,254 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	errorx "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/provider"
+	"github.com/zeromicro/x/test"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type uploadPart struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func newUploadRequest(t *testing.T, parts ...uploadPart) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, part := range parts {
+		var (
+			w   io.Writer
+			err error
+		)
+		if len(part.filename) > 0 {
+			w, err = mw.CreateFormFile(part.field, part.filename)
+		} else {
+			w, err = mw.CreateFormField(part.field)
+		}
+		assert.NoError(t, err)
+		_, err = w.Write(part.content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mw.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func sha256Hex(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseUpload(t *testing.T) {
+	SetIDGenerator(test.NewSequenceIDGenerator("file"))
+	defer SetIDGenerator(provider.UUIDGenerator)
+
+	sink := NewMemoryUploadSink()
+	r := newUploadRequest(t,
+		uploadPart{field: "name", content: []byte("avatar")},
+		uploadPart{field: "avatar", filename: "a.png", content: pngHeader},
+		uploadPart{field: "note", filename: "a.txt", content: []byte("hello")},
+	)
+	result, err := ParseUpload(r, sink, WithAllowedTypes("image/*", "text/plain"))
+	assert.NoError(t, err)
+	assert.Equal(t, "avatar", result.Values.Get("name"))
+	assert.Equal(t, []UploadedFile{
+		{
+			Field:       "avatar",
+			Filename:    "a.png",
+			ContentType: "image/png",
+			Size:        int64(len(pngHeader)),
+			Checksum:    sha256Hex(pngHeader),
+			Location:    "file-1",
+		},
+		{
+			Field:       "note",
+			Filename:    "a.txt",
+			ContentType: "text/plain; charset=utf-8",
+			Size:        5,
+			Checksum:    sha256Hex([]byte("hello")),
+			Location:    "file-2",
+		},
+	}, result.Files)
+
+	content, ok := sink.Get("file-1")
+	assert.True(t, ok)
+	assert.Equal(t, pngHeader, content)
+	content, ok = sink.Get("file-2")
+	assert.True(t, ok)
+	assert.Equal(t, "hello", string(content))
+}
+
+func TestParseUploadLargeFile(t *testing.T) {
+	sink := NewMemoryUploadSink()
+	content := bytes.Repeat([]byte("a"), 4096)
+	result, err := ParseUpload(newUploadRequest(t, uploadPart{field: "f", filename: "a.txt", content: content}),
+		sink, WithMaxFileSize(4096))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4096), result.Files[0].Size)
+	assert.Equal(t, sha256Hex(content), result.Files[0].Checksum)
+
+	result, err = ParseUpload(newUploadRequest(t, uploadPart{field: "f", filename: "a.txt", content: content}),
+		sink, WithChecksum(md5.New))
+	assert.NoError(t, err)
+	sum := md5.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), result.Files[0].Checksum)
+}
+
+func TestParseUploadErrors(t *testing.T) {
+	type input struct {
+		parts []uploadPart
+		opts  []UploadOption
+	}
+
+	txt := uploadPart{field: "f", filename: "a.txt", content: []byte("hello")}
+	executor := test.NewExecutor[input, string]()
+	executor.Add([]test.Data[input, string]{
+		{
+			Name: "file too large",
+			Input: input{
+				parts: []uploadPart{txt, {field: "f", filename: "b.txt", content: []byte("hello world")}},
+				opts:  []UploadOption{WithMaxFileSize(10)},
+			},
+			Want: `{"code":41300,"msg":"file b.txt exceeds the limit of 10 bytes"}`,
+		},
+		{
+			Name: "request too large",
+			Input: input{
+				parts: []uploadPart{txt, txt, txt},
+				opts:  []UploadOption{WithMaxUploadSize(12)},
+			},
+			Want: `{"code":41300,"msg":"request exceeds the limit of 12 bytes"}`,
+		},
+		{
+			Name: "value too large",
+			Input: input{
+				parts: []uploadPart{txt, {field: "name", content: []byte("anyone")}},
+				opts:  []UploadOption{WithMaxUploadSize(8)},
+			},
+			Want: `{"code":41300,"msg":"request exceeds the limit of 8 bytes"}`,
+		},
+		{
+			Name: "too many files",
+			Input: input{
+				parts: []uploadPart{txt, txt},
+				opts:  []UploadOption{WithMaxFiles(1)},
+			},
+			Want: `{"code":41300,"msg":"too many files, the limit is 1"}`,
+		},
+		{
+			Name: "type not allowed",
+			Input: input{
+				parts: []uploadPart{{field: "f", filename: "a.png", content: pngHeader}, txt},
+				opts:  []UploadOption{WithAllowedTypes("image/*")},
+			},
+			Want: `{"code":41500,"msg":"file a.txt of type text/plain; charset=utf-8 is not allowed"}`,
+		},
+	}...)
+
+	executor.Run(t, func(in input) string {
+		sink := NewMemoryUploadSink()
+		_, err := ParseUpload(newUploadRequest(t, in.parts...), sink, in.opts...)
+		assert.Error(t, err)
+		// the saved files are removed on failures
+		assert.Equal(t, 0, sink.Len())
+
+		w := httptest.NewRecorder()
+		JsonBaseResponse(w, err)
+		return w.Body.String()
+	})
+}
+
+func TestParseUploadInvalidForm(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{"name":"anyone"}`))
+	r.Header.Set("Content-Type", "application/json")
+	_, err := ParseUpload(r, NewMemoryUploadSink())
+	assert.True(t, errorx.IsKind(err, errorx.KindInvalidParams))
+	status, ok := errorx.HTTPStatus(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	r = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("--boundary\r\nbroken"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=boundary")
+	_, err = ParseUpload(r, NewMemoryUploadSink())
+	assert.True(t, errorx.IsKind(err, errorx.KindInvalidParams))
+}
+
+type failedUploadSink struct {
+	*MemoryUploadSink
+}
+
+func (s failedUploadSink) Save(ctx context.Context, file *UploadedFile, r io.Reader) (string, error) {
+	if file.Filename == "fail.txt" {
+		return "", errors.New("disk full")
+	}
+
+	return s.MemoryUploadSink.Save(ctx, file, r)
+}
+
+func TestParseUploadSinkError(t *testing.T) {
+	sink := failedUploadSink{MemoryUploadSink: NewMemoryUploadSink()}
+	_, err := ParseUpload(newUploadRequest(t,
+		uploadPart{field: "f", filename: "a.txt", content: []byte("hello")},
+		uploadPart{field: "f", filename: "fail.txt", content: []byte("hello")},
+	), sink)
+	assert.True(t, errorx.IsKind(err, errorx.KindInternal))
+	assert.Contains(t, err.Error(), "disk full")
+	assert.Equal(t, 0, sink.Len())
+}
+
+func TestDiskUploadSink(t *testing.T) {
+	SetIDGenerator(test.NewSequenceIDGenerator("file"))
+	defer SetIDGenerator(provider.UUIDGenerator)
+
+	dir := filepath.Join(t.TempDir(), "uploads")
+	sink := NewDiskUploadSink(dir)
+	result, err := ParseUpload(newUploadRequest(t,
+		uploadPart{field: "avatar", filename: "../A.GIF", content: pngHeader},
+		uploadPart{field: "note", filename: "note.php", content: []byte("hello")},
+		uploadPart{field: "page", filename: "page.png", content: []byte("<html><script></script></html>")},
+	), sink)
+	assert.NoError(t, err)
+	// the extensions are derived from the sniffed content types, not the file names
+	assert.Equal(t, filepath.Join(dir, "file-1.png"), result.Files[0].Location)
+	assert.Equal(t, filepath.Join(dir, "file-2.txt"), result.Files[1].Location)
+	assert.Equal(t, filepath.Join(dir, "file-3"), result.Files[2].Location)
+
+	content, err := os.ReadFile(result.Files[0].Location)
+	assert.NoError(t, err)
+	assert.Equal(t, pngHeader, content)
+
+	assert.NoError(t, sink.Remove(context.Background(), result.Files[0].Location))
+	assert.NoError(t, sink.Remove(context.Background(), result.Files[0].Location))
+	_, err = os.Stat(result.Files[0].Location)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = ParseUpload(newUploadRequest(t,
+		uploadPart{field: "f", filename: "a.txt", content: []byte("hello")},
+		uploadPart{field: "f", filename: "b.txt", content: []byte("hello world")},
+	), sink, WithMaxFileSize(10))
+	assert.True(t, errorx.IsKind(err, errorx.KindPayloadTooLarge))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -11,9 +11,14 @@ const (
 
 	// BusinessCodeError represents the business code for error.
 	BusinessCodeError = -1
+	// BusinessMsgInternalError represents the business message for the errors unsafe to expose.
+	BusinessMsgInternalError = "internal error"
 
 	// XmlContentType represents the content type for xml.
 	XmlContentType = "application/xml"
 	// HTMLContentType represents the content type for html.
 	HTMLContentType = "application/html"
+
+	retryAfterHeader = "Retry-After"
+	correlationIDKey = "correlationId"
 )
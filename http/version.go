@@ -0,0 +1,346 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/x/errors"
+)
+
+const (
+	// VersionV1 represents the envelope v1, which is BaseResponse.
+	VersionV1 Version = iota + 1
+	// VersionV2 represents the envelope v2, which is BaseResponseV2 with the response metadata.
+	VersionV2
+
+	latestVersion        = VersionV2
+	defaultVersionHeader = "X-Api-Version"
+	defaultVendor        = "x"
+	deprecationHeader    = "Deprecation"
+	sunsetHeader         = "Sunset"
+)
+
+type (
+	// Version represents the version of the response envelope.
+	Version int
+
+	// BaseResponseV2 is the base response struct of VersionV2, with the response metadata.
+	BaseResponseV2[T any] struct {
+		// Code represents the business code, not the http status code.
+		Code int `json:"code" xml:"code"`
+		// Msg represents the business message.
+		Msg string `json:"msg" xml:"msg"`
+		// Data represents the business data.
+		Data T `json:"data,omitempty" xml:"data,omitempty"`
+		// Meta represents the response metadata.
+		Meta ResponseMeta `json:"meta" xml:"meta"`
+	}
+
+	// ResponseMeta is the metadata of BaseResponseV2.
+	ResponseMeta struct {
+		// RequestID represents the unique ID of the response.
+		RequestID string `json:"requestId" xml:"requestId"`
+		// Timestamp represents the time when the response is written.
+		Timestamp time.Time `json:"timestamp" xml:"timestamp"`
+		// CorrelationID represents the ID to look up the logs of a redacted error.
+		CorrelationID string `json:"correlationId,omitempty" xml:"correlationId,omitempty"`
+		// Caller represents the location where the error is created, see SetIncludeCaller.
+		Caller string `json:"caller,omitempty" xml:"caller,omitempty"`
+	}
+
+	// VersionOption represents an option for VersionMiddleware.
+	VersionOption func(*versionOptions)
+
+	baseXmlResponseV2[T any] struct {
+		XMLName  xml.Name `xml:"xml"`
+		Version  string   `xml:"version,attr"`
+		Encoding string   `xml:"encoding,attr"`
+		BaseResponseV2[T]
+	}
+
+	versionKey   struct{}
+	mediaTypeKey struct{}
+
+	deprecation struct {
+		deprecatedAt time.Time
+		sunset       time.Time
+	}
+
+	versionOptions struct {
+		header         string
+		vendor         string
+		prefix         bool
+		defaultVersion Version
+		deprecations   map[Version]deprecation
+	}
+)
+
+// WithVersionHeader is an option to set the request header carrying the version, defaults to X-Api-Version.
+func WithVersionHeader(header string) VersionOption {
+	return func(o *versionOptions) {
+		o.header = header
+	}
+}
+
+// WithVersionVendor is an option to set the vendor of the media types,
+// like application/vnd.<vendor>.v2+json, defaults to x.
+func WithVersionVendor(vendor string) VersionOption {
+	return func(o *versionOptions) {
+		o.vendor = vendor
+	}
+}
+
+// WithoutVersionPrefix is an option to ignore the URL prefixes like /v2/.
+func WithoutVersionPrefix() VersionOption {
+	return func(o *versionOptions) {
+		o.prefix = false
+	}
+}
+
+// WithDefaultVersion is an option to set the version of the requests not specifying one, defaults to VersionV1.
+func WithDefaultVersion(v Version) VersionOption {
+	return func(o *versionOptions) {
+		o.defaultVersion = v
+	}
+}
+
+// WithDeprecatedVersion is an option to mark v as deprecated, the responses of v get the Deprecation header,
+// and the Sunset header if sunset is not zero. The Deprecation header is true if deprecatedAt is zero.
+func WithDeprecatedVersion(v Version, deprecatedAt, sunset time.Time) VersionOption {
+	return func(o *versionOptions) {
+		o.deprecations[v] = deprecation{
+			deprecatedAt: deprecatedAt,
+			sunset:       sunset,
+		}
+	}
+}
+
+// VersionMiddleware returns a middleware which negotiates the envelope version of the requests,
+// from the version header, the URL prefix like /v2/, or the media type like application/vnd.x.v2+json
+// in the Accept header, in order. The base response writers with context, like JsonBaseResponseCtx,
+// write the envelope of the negotiated version. The unsupported versions, including the URL prefixes like /v3/,
+// get an errors.KindInvalidParams base response with http.StatusBadRequest.
+// If the version is negotiated from a media type like application/vnd.x.v2+json, the base responses
+// in the format of the media type, written by the writers with context, get the media type as Content-Type,
+// except the ones written by httpx.ErrorCtx, since the error handlers of httpx write their own Content-Type.
+// The responses vary by the Accept header and the version header.
+func VersionMiddleware(opts ...VersionOption) func(http.HandlerFunc) http.HandlerFunc {
+	o := versionOptions{
+		header:         defaultVersionHeader,
+		vendor:         defaultVendor,
+		prefix:         true,
+		defaultVersion: VersionV1,
+		deprecations:   make(map[Version]deprecation),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add(varyHeader, acceptHeader)
+			w.Header().Add(varyHeader, o.header)
+			v, mediaType, ok := o.negotiate(r)
+			if !ok {
+				writeBaseResponse(r.Context(), w, http.StatusBadRequest,
+					errors.InvalidParams("unsupported api version"), wantsXml(r))
+				return
+			}
+
+			if d, ok := o.deprecations[v]; ok {
+				header := w.Header()
+				if d.deprecatedAt.IsZero() {
+					header.Set(deprecationHeader, "true")
+				} else {
+					header.Set(deprecationHeader, "@"+strconv.FormatInt(d.deprecatedAt.Unix(), 10))
+				}
+				if !d.sunset.IsZero() {
+					header.Set(sunsetHeader, d.sunset.UTC().Format(http.TimeFormat))
+				}
+			}
+
+			ctx := WithVersion(r.Context(), v)
+			if len(mediaType) > 0 {
+				ctx = context.WithValue(ctx, mediaTypeKey{}, mediaType)
+			}
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// WithVersion returns a copy of ctx with the envelope version v.
+func WithVersion(ctx context.Context, v Version) context.Context {
+	return context.WithValue(ctx, versionKey{}, v)
+}
+
+// VersionFromContext returns the envelope version in ctx, VersionV1 if absent.
+func VersionFromContext(ctx context.Context) Version {
+	if v, ok := ctx.Value(versionKey{}).(Version); ok {
+		return v
+	}
+
+	return VersionV1
+}
+
+func (v Version) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+// negotiate returns the version of r, and the media type if the version is negotiated from the Accept header.
+func (o versionOptions) negotiate(r *http.Request) (Version, string, bool) {
+	if value := r.Header.Get(o.header); len(value) > 0 {
+		v, ok := parseVersion(value)
+		return v, "", ok
+	}
+
+	if o.prefix {
+		segment := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		if isVersionSegment(segment) {
+			v, ok := parseVersion(segment)
+			return v, "", ok
+		}
+	}
+
+	prefix := "application/vnd." + o.vendor + "."
+	for _, part := range strings.Split(r.Header.Get(acceptHeader), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.HasPrefix(mediaType, prefix) {
+			continue
+		}
+
+		value := strings.TrimPrefix(mediaType, prefix)
+		if i := strings.IndexByte(value, '+'); i >= 0 {
+			value = value[:i]
+		}
+		v, ok := parseVersion(value)
+		return v, mediaType, ok
+	}
+
+	return o.defaultVersion, "", true
+}
+
+// isVersionSegment reports whether segment is a version like v2.
+func isVersionSegment(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+
+	for _, c := range segment[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mediaTypeFormat returns the format of the structured syntax suffix of mediaType, json or xml,
+// or empty if it has neither.
+func mediaTypeFormat(mediaType string) string {
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "+xml"):
+		return "xml"
+	default:
+		return ""
+	}
+}
+
+// parseVersion parses the versions like 2 or v2.
+func parseVersion(s string) (Version, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(s), "v"))
+	if err != nil || n < int(VersionV1) || n > int(latestVersion) {
+		return 0, false
+	}
+
+	return Version(n), true
+}
+
+// jsonEnvelope returns the json envelope of resp in the version of ctx.
+func jsonEnvelope(ctx context.Context, resp BaseResponse[any]) any {
+	if VersionFromContext(ctx) == VersionV2 {
+		return wrapBaseResponseV2(resp)
+	}
+
+	return resp
+}
+
+// xmlEnvelope returns the xml envelope of resp in the version of ctx.
+func xmlEnvelope(ctx context.Context, resp BaseResponse[any]) any {
+	if VersionFromContext(ctx) == VersionV2 {
+		return baseXmlResponseV2[any]{
+			Version:        xmlVersion,
+			Encoding:       xmlEncoding,
+			BaseResponseV2: wrapBaseResponseV2(resp),
+		}
+	}
+
+	return wrapXmlResponse(resp)
+}
+
+// writeJsonEnvelope writes the json envelope of resp in the version of ctx into w with code.
+func writeJsonEnvelope(ctx context.Context, w http.ResponseWriter, code int, resp BaseResponse[any]) {
+	contentType := envelopeContentType(ctx, "json", httpx.JsonContentType)
+	if err := doWriteEnvelope(w, code, contentType, json.Marshal, jsonEnvelope(ctx, resp)); err != nil {
+		logx.WithContext(ctx).Error(err)
+	}
+}
+
+// writeXmlEnvelope writes the xml envelope of resp in the version of ctx into w with code.
+func writeXmlEnvelope(ctx context.Context, w http.ResponseWriter, code int, resp BaseResponse[any]) {
+	contentType := envelopeContentType(ctx, "xml", XmlContentType)
+	if err := doWriteEnvelope(w, code, contentType, xml.Marshal, xmlEnvelope(ctx, resp)); err != nil {
+		logx.WithContext(ctx).Error(err)
+	}
+}
+
+func doWriteEnvelope(w http.ResponseWriter, code int, contentType string,
+	marshal func(any) ([]byte, error), v any) error {
+	bs, err := marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return fmt.Errorf("marshal response failed, error: %w", err)
+	}
+
+	return writeBytes(w, code, contentType, bs)
+}
+
+// envelopeContentType returns the media type negotiated by VersionMiddleware in ctx with the parameters
+// of defaultType, like the charset, if it's in format, json or xml, otherwise defaultType.
+func envelopeContentType(ctx context.Context, format, defaultType string) string {
+	mediaType, ok := ctx.Value(mediaTypeKey{}).(string)
+	if !ok || mediaTypeFormat(mediaType) != format {
+		return defaultType
+	}
+
+	_, params, err := mime.ParseMediaType(defaultType)
+	if err != nil {
+		return mediaType
+	}
+
+	return mime.FormatMediaType(mediaType, params)
+}
+
+func wrapBaseResponseV2(resp BaseResponse[any]) BaseResponseV2[any] {
+	return BaseResponseV2[any]{
+		Code: resp.Code,
+		Msg:  resp.Msg,
+		Data: resp.Data,
+		Meta: ResponseMeta{
+			RequestID:     newID(),
+			Timestamp:     now(),
+			CorrelationID: resp.CorrelationID,
+			Caller:        resp.Caller,
+		},
+	}
+}
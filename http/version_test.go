@@ -0,0 +1,228 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	errorx "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/provider"
+	"github.com/zeromicro/x/test"
+)
+
+func fakeVersionProviders(t *testing.T) {
+	SetClock(test.NewFakeClock(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)))
+	SetIDGenerator(test.NewSequenceIDGenerator("req"))
+	t.Cleanup(func() {
+		SetClock(provider.SystemClock)
+		SetIDGenerator(provider.UUIDGenerator)
+	})
+}
+
+func TestVersionMiddleware(t *testing.T) {
+	type input struct {
+		path   string
+		header map[string]string
+	}
+
+	middleware := VersionMiddleware(WithVersionVendor("acme"))
+	executor := test.NewExecutor[input, Version]()
+	executor.Add([]test.Data[input, Version]{
+		{Name: "default", Input: input{path: "/users"}, Want: VersionV1},
+		{Name: "header", Input: input{path: "/users", header: map[string]string{"X-Api-Version": "2"}}, Want: VersionV2},
+		{Name: "header-prefixed", Input: input{path: "/v2/users", header: map[string]string{"X-Api-Version": "v1"}},
+			Want: VersionV1},
+		{Name: "prefix", Input: input{path: "/v2/users"}, Want: VersionV2},
+		{Name: "not-prefix", Input: input{path: "/2/users"}, Want: VersionV1},
+		{Name: "not-version-prefix", Input: input{path: "/videos"}, Want: VersionV1},
+		{Name: "media-type", Input: input{path: "/users", header: map[string]string{
+			"Accept": "text/html, application/vnd.acme.v2+json"}}, Want: VersionV2},
+		{Name: "other-vendor", Input: input{path: "/users", header: map[string]string{
+			"Accept": "application/vnd.x.v2+json"}}, Want: VersionV1},
+	}...)
+	executor.Run(t, func(in input) Version {
+		r := httptest.NewRequest(http.MethodGet, in.path, http.NoBody)
+		for k, v := range in.header {
+			r.Header.Set(k, v)
+		}
+
+		var version Version
+		middleware(func(w http.ResponseWriter, r *http.Request) {
+			version = VersionFromContext(r.Context())
+		})(httptest.NewRecorder(), r)
+		return version
+	})
+}
+
+func TestVersionMiddlewareUnsupported(t *testing.T) {
+	handler := VersionMiddleware()(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("should not be handled")
+	})
+
+	for _, version := range []string{"3", "v0", "latest"} {
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		r.Header.Set("X-Api-Version", version)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"code":40000,"msg":"unsupported api version"}`, w.Body.String())
+	}
+
+	for _, path := range []string{"/v3/users", "/v0"} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+func TestVersionMiddlewareMediaType(t *testing.T) {
+	fakeVersionProviders(t)
+	handler := VersionMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xml":
+			XmlBaseResponseCtx(r.Context(), w, message{Name: "anyone"})
+		case "/plain":
+			JsonBaseResponse(w, message{Name: "anyone"})
+		case "/conditional":
+			ConditionalJsonBaseResponse(w, r, message{Name: "anyone"})
+		default:
+			JsonBaseResponseCtx(r.Context(), w, message{Name: "anyone"})
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/json", http.NoBody)
+	r.Header.Set("Accept", "application/vnd.x.v2+json")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, "application/vnd.x.v2+json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"Accept", "X-Api-Version"}, w.Header().Values("Vary"))
+	assert.Contains(t, w.Body.String(), `"meta"`)
+
+	// the writers without context write the v1 envelope, they keep the content type
+	r = httptest.NewRequest(http.MethodGet, "/plain", http.NoBody)
+	r.Header.Set("Accept", "application/vnd.x.v2+json")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), `"meta"`)
+
+	r = httptest.NewRequest(http.MethodGet, "/conditional", http.NoBody)
+	r.Header.Set("Accept", "application/vnd.x.v2+json")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, "application/vnd.x.v2+json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	r = httptest.NewRequest(http.MethodGet, "/xml", http.NoBody)
+	r.Header.Set("Accept", "application/vnd.x.v1+xml")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, "application/vnd.x.v1+xml", w.Header().Get("Content-Type"))
+
+	// the json responses keep their content type if the negotiated media type is xml
+	r = httptest.NewRequest(http.MethodGet, "/json", http.NoBody)
+	r.Header.Set("Accept", "application/vnd.x.v1+xml")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	r = httptest.NewRequest(http.MethodGet, "/v2/json", http.NoBody)
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"Accept", "X-Api-Version"}, w.Header().Values("Vary"))
+}
+
+func TestVersionMiddlewareDeprecation(t *testing.T) {
+	deprecatedAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := VersionMiddleware(WithDeprecatedVersion(VersionV1, deprecatedAt, sunset),
+		WithVersionHeader("Api-Version"), WithoutVersionPrefix(), WithDefaultVersion(VersionV2))(
+		func(w http.ResponseWriter, r *http.Request) {})
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/users", http.NoBody)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	r.Header.Set("Api-Version", "1")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, "@1672531200", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 01 Jan 2024 00:00:00 GMT", w.Header().Get("Sunset"))
+
+	handler = VersionMiddleware(WithDeprecatedVersion(VersionV1, time.Time{}, time.Time{}))(
+		func(w http.ResponseWriter, r *http.Request) {})
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+}
+
+func TestBaseResponseV2(t *testing.T) {
+	fakeVersionProviders(t)
+	ctx := WithVersion(context.Background(), VersionV2)
+
+	w := httptest.NewRecorder()
+	JsonBaseResponseCtx(ctx, w, message{Name: "anyone"})
+	assert.Equal(t, `{"code":0,"msg":"ok","data":{"name":"anyone"},`+
+		`"meta":{"requestId":"req-1","timestamp":"2023-01-02T03:04:05Z"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	XmlBaseResponseCtx(ctx, w, errorx.New(1001, "test"))
+	assert.Equal(t, `<xml version="1.0" encoding="UTF-8"><code>1001</code><msg>test</msg>`+
+		`<meta><requestId>req-2</requestId><timestamp>2023-01-02T03:04:05Z</timestamp></meta></xml>`,
+		w.Body.String())
+
+	// the non-context writers always write v1
+	w = httptest.NewRecorder()
+	JsonBaseResponse(w, message{Name: "anyone"})
+	assert.Equal(t, `{"code":0,"msg":"ok","data":{"name":"anyone"}}`, w.Body.String())
+	assert.Equal(t, "v2", VersionV2.String())
+}
+
+func TestErrorHandlerV2(t *testing.T) {
+	fakeVersionProviders(t)
+	SetupErrorHandler(nil)
+	defer resetErrorHandler()
+
+	w := httptest.NewRecorder()
+	httpx.ErrorCtx(WithVersion(context.Background(), VersionV2), w, errors.New("test"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"code":40000,"msg":"test","meta":{"requestId":"req-1","timestamp":"2023-01-02T03:04:05Z"}}`,
+		w.Body.String())
+}
+
+func TestConditionalV2(t *testing.T) {
+	handler := VersionMiddleware()(func(w http.ResponseWriter, r *http.Request) {
+		ConditionalJsonBaseResponse(w, r, message{Name: "anyone"})
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/v2/config", http.NoBody)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`), etag)
+
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// the v1 and v2 responses have different ETags
+	r = httptest.NewRequest(http.MethodGet, "/v1/config", http.NoBody)
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.NotEqual(t, strings.TrimPrefix(etag, "W/"), w.Header().Get("ETag"))
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
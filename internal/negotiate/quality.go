@@ -0,0 +1,28 @@
+// Package negotiate provides the helpers of the http content negotiation shared by the packages of this module.
+package negotiate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseQuality returns the q parameter in params, the parameters of an element in the headers
+// like Accept and Accept-Language, such as "level=1;q=0.5", it's 1 if absent.
+// It returns false if the q parameter is malformed or out of [0, 1].
+func ParseQuality(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0, false
+		}
+
+		return q, true
+	}
+
+	return 1, true
+}
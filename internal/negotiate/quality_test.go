@@ -0,0 +1,29 @@
+package negotiate
+
+import (
+	"testing"
+
+	"github.com/zeromicro/x/test"
+)
+
+func TestParseQuality(t *testing.T) {
+	type result struct {
+		Q  float64
+		Ok bool
+	}
+
+	executor := test.NewExecutor[string, result]()
+	executor.Add([]test.Data[string, result]{
+		{Name: "absent", Input: "", Want: result{Q: 1, Ok: true}},
+		{Name: "other-params", Input: "level=1", Want: result{Q: 1, Ok: true}},
+		{Name: "quality", Input: "level=1; Q = 0.5", Want: result{Q: 0.5, Ok: true}},
+		{Name: "zero", Input: "q=0", Want: result{Ok: true}},
+		{Name: "malformed", Input: "q=abc"},
+		{Name: "out-of-range", Input: "q=1.5"},
+		{Name: "negative", Input: "q=-1"},
+	}...)
+	executor.Run(t, func(params string) result {
+		q, ok := ParseQuality(params)
+		return result{Q: q, Ok: ok}
+	})
+}
@@ -0,0 +1,61 @@
+// Command xopenapi generates the OpenAPI 3 components of the base response envelope
+// and the error codes, for example:
+//
+//	xopenapi -title "order api" -version 1.0 -errors errors.json -o openapi.json
+//
+// The errors file is a json array of the extra error codes, like:
+//
+//	[{"Code": 1001, "Msg": "order not found", "HTTPStatus": 404}]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/openapi"
+)
+
+var (
+	title      = flag.String("title", "api", "the title of the api")
+	version    = flag.String("version", "1.0.0", "the version of the api")
+	errorsFile = flag.String("errors", "", "the json file of the extra error codes")
+	output     = flag.String("o", "", "the output file, defaults to stdout")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	g := openapi.NewGenerator(*title, *version)
+	if len(*errorsFile) > 0 {
+		content, err := os.ReadFile(*errorsFile)
+		if err != nil {
+			return err
+		}
+
+		var entries []errors.Entry
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return fmt.Errorf("parse %s failed, error: %w", *errorsFile, err)
+		}
+		g.AddErrors(entries...)
+	}
+
+	content, err := json.MarshalIndent(g.Document(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if len(*output) == 0 {
+		_, err = fmt.Println(string(content))
+		return err
+	}
+
+	return os.WriteFile(*output, append(content, '\n'), 0o644)
+}
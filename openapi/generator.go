@@ -0,0 +1,348 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/x/errors"
+	xhttp "github.com/zeromicro/x/http"
+)
+
+const (
+	baseResponseName  = "BaseResponse"
+	errorResponseName = "ErrorResponse"
+	jsonMediaType     = "application/json"
+	xmlMediaType      = "application/xml"
+	xmlRootName       = "xml"
+	defaultResponse   = "default"
+)
+
+type (
+	// Endpoint describes an API endpoint whose responses are written by the base response writers.
+	Endpoint struct {
+		// Method represents the http method, like GET.
+		Method string
+		// Path represents the route path, the go-zero style parameters like :id are converted into {id}.
+		Path        string
+		OperationID string
+		Summary     string
+		// Request represents a value of the request body type, nil if the endpoint has no body.
+		Request any
+		// Response represents a value of the data type in the BaseResponse, nil if there's no data.
+		Response any
+		// Errors represents the business codes the endpoint may respond, they're in the http.StatusOK response
+		// besides the data, unless ErrorHandler is set.
+		Errors []int
+		// Xml represents whether the endpoint responds xml by XmlBaseResponse, besides json.
+		Xml bool
+		// ErrorHandler represents whether the endpoint responds the errors by httpx.ErrorCtx with the error handler
+		// set up by http.SetupErrorHandler, rather than by JsonBaseResponse, which always responds http.StatusOK.
+		// If it's set, the error codes are grouped by the http status of their entries in the errors catalog,
+		// the others are in the default response.
+		ErrorHandler bool
+	}
+
+	// Generator generates an OpenAPI 3 document of the endpoints, with the responses wrapped
+	// in the BaseResponse envelope and the possible error codes.
+	Generator struct {
+		doc   Document
+		names map[reflect.Type]string
+		// envelopes are the names of the BaseResponse envelopes of the data types.
+		envelopes map[reflect.Type]string
+		errors    map[int]errors.Entry
+	}
+)
+
+// NewGenerator creates a Generator of the API with title and version,
+// the entries of the errors catalog, including the registered ones, are known error codes.
+func NewGenerator(title, version string) *Generator {
+	g := &Generator{
+		doc: Document{
+			OpenAPI: Version,
+			Info: Info{
+				Title:   title,
+				Version: version,
+			},
+			Paths: make(map[string]PathItem),
+			Components: Components{
+				Schemas: make(map[string]*Schema),
+			},
+		},
+		names:     make(map[reflect.Type]string),
+		envelopes: make(map[reflect.Type]string),
+		errors:    make(map[int]errors.Entry),
+	}
+	for _, entry := range errors.Entries() {
+		g.errors[entry.Code] = entry
+	}
+	g.doc.Components.Schemas[baseResponseName] = g.baseResponseSchema()
+
+	return g
+}
+
+// AddErrors adds the error codes, which are not in the errors catalog, as known error codes.
+func (g *Generator) AddErrors(entries ...errors.Entry) {
+	for _, entry := range entries {
+		g.errors[entry.Code] = entry
+	}
+}
+
+// AddEndpoint adds ep as an operation of the document.
+func (g *Generator) AddEndpoint(ep Endpoint) {
+	path, params := convertPath(ep.Path)
+	op := &Operation{
+		OperationID: ep.OperationID,
+		Summary:     ep.Summary,
+		Parameters:  params,
+		Responses:   make(map[string]*Response),
+	}
+	if ep.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  g.content(g.schemaOf(reflect.TypeOf(ep.Request)), nil),
+		}
+	}
+
+	op.Responses[strconv.Itoa(http.StatusOK)] = &Response{
+		Description: "ok",
+		Content:     g.envelope(ep.Response, ep.Xml),
+	}
+	if ep.ErrorHandler {
+		g.addErrorResponses(op, ep)
+	} else {
+		g.addOkErrors(op, ep)
+	}
+
+	item, ok := g.doc.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		g.doc.Paths[path] = item
+	}
+	item[strings.ToLower(ep.Method)] = op
+}
+
+// Document returns the generated document, the error response schema lists all the known error codes.
+func (g *Generator) Document() *Document {
+	codes := make([]int, 0, len(g.errors))
+	for code := range g.errors {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	g.doc.Components.Schemas[errorResponseName] = g.errorSchema(codes)
+
+	return &g.doc
+}
+
+// MarshalJSON returns the generated document as json.
+func (g *Generator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.Document())
+}
+
+// envelope returns the content of the base response with the data of v, in json and optionally in xml.
+func (g *Generator) envelope(v any, withXml bool) map[string]MediaType {
+	schema := RefOf(baseResponseName)
+	if v != nil {
+		t := reflect.TypeOf(v)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		name, ok := g.envelopes[t]
+		if !ok {
+			// the data schema is built first, so that the envelope is named after the unique name of the type
+			data := g.schemaOf(t)
+			name = g.uniqueName(baseResponseName + "_" + g.typeName(t))
+			g.envelopes[t] = name
+			g.doc.Components.Schemas[name] = &Schema{
+				AllOf: []*Schema{
+					RefOf(baseResponseName),
+					{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"data": data,
+						},
+					},
+				},
+			}
+		}
+		schema = RefOf(name)
+	}
+
+	if !withXml {
+		return g.content(schema, nil)
+	}
+
+	return g.content(schema, xmlEnvelope(schema))
+}
+
+// addOkErrors adds the error codes of ep into the http.StatusOK response, as JsonBaseResponse responds them.
+func (g *Generator) addOkErrors(op *Operation, ep Endpoint) {
+	if len(ep.Errors) == 0 {
+		return
+	}
+
+	codes := make([]int, 0, len(ep.Errors))
+	for _, code := range ep.Errors {
+		g.addError(code)
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	resp := op.Responses[strconv.Itoa(http.StatusOK)]
+	resp.Description = "ok, or the errors:\n" + g.describeErrors(codes)
+	schema := g.errorSchema(codes)
+	for mediaType, content := range resp.Content {
+		errSchema := schema
+		if mediaType == xmlMediaType {
+			errSchema = xmlEnvelope(schema)
+		}
+		resp.Content[mediaType] = MediaType{Schema: &Schema{OneOf: []*Schema{content.Schema, errSchema}}}
+	}
+}
+
+// addErrorResponses adds the error codes of ep as the responses of the http statuses in the errors catalog,
+// as the error handler set up by http.SetupErrorHandler responds them.
+func (g *Generator) addErrorResponses(op *Operation, ep Endpoint) {
+	groups := make(map[string][]int)
+	for _, code := range ep.Errors {
+		status := defaultResponse
+		if entry := g.addError(code); entry.HTTPStatus != 0 {
+			status = strconv.Itoa(entry.HTTPStatus)
+		}
+		groups[status] = append(groups[status], code)
+	}
+
+	for status, codes := range groups {
+		sort.Ints(codes)
+		schema := g.errorSchema(codes)
+		var xmlSchema *Schema
+		if ep.Xml {
+			xmlSchema = xmlEnvelope(schema)
+		}
+		op.Responses[status] = &Response{
+			Description: g.describeErrors(codes),
+			Content:     g.content(schema, xmlSchema),
+		}
+	}
+}
+
+// addError adds code as a known error code if it's unknown, and returns its entry.
+func (g *Generator) addError(code int) errors.Entry {
+	entry, ok := g.errors[code]
+	if !ok {
+		entry = errors.Entry{Code: code}
+		g.errors[code] = entry
+	}
+
+	return entry
+}
+
+func (g *Generator) errorSchema(codes []int) *Schema {
+	enum := make([]any, 0, len(codes))
+	for _, code := range codes {
+		enum = append(enum, code)
+	}
+
+	return &Schema{
+		AllOf: []*Schema{
+			RefOf(baseResponseName),
+			{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"code": {Type: "integer", Format: "int32", Enum: enum, Description: g.describeErrors(codes)},
+				},
+			},
+		},
+	}
+}
+
+func (g *Generator) describeErrors(codes []int) string {
+	lines := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if msg := g.errors[code].Msg; len(msg) > 0 {
+			lines = append(lines, fmt.Sprintf("%d: %s", code, msg))
+		} else {
+			lines = append(lines, strconv.Itoa(code))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (g *Generator) content(schema, xmlSchema *Schema) map[string]MediaType {
+	content := map[string]MediaType{
+		jsonMediaType: {Schema: schema},
+	}
+	if xmlSchema != nil {
+		content[xmlMediaType] = MediaType{Schema: xmlSchema}
+	}
+
+	return content
+}
+
+func (g *Generator) typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	if len(t.Name()) > 0 {
+		return schemaName(t)
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		return "List_" + g.typeName(t.Elem())
+	}
+
+	return t.Kind().String()
+}
+
+// xmlEnvelope returns the schema of the <xml version="1.0" encoding="UTF-8"> wrapper of schema.
+func xmlEnvelope(schema *Schema) *Schema {
+	return &Schema{
+		AllOf: []*Schema{
+			schema,
+			{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"version":  {Type: "string", XML: &XML{Attribute: true}},
+					"encoding": {Type: "string", XML: &XML{Attribute: true}},
+				},
+			},
+		},
+		XML: &XML{Name: xmlRootName},
+	}
+}
+
+// baseResponseSchema reflects over BaseResponse, the data property is added per endpoint.
+func (g *Generator) baseResponseSchema() *Schema {
+	schema := g.structSchema(reflect.TypeOf(xhttp.BaseResponse[any]{}))
+	delete(schema.Properties, "data")
+	return schema
+}
+
+// convertPath converts the go-zero style path parameters like :id into {id}, and returns them.
+func convertPath(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	return strings.Join(segments, "/"), params
+}
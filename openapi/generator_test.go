@@ -0,0 +1,164 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/x/errors"
+	xhttp "github.com/zeromicro/x/http"
+)
+
+type (
+	user struct {
+		ID       int64     `json:"id" xml:"id,attr"`
+		Name     string    `json:"name"`
+		Email    *string   `json:"email"`
+		Tags     []string  `json:"tags,omitempty"`
+		Created  time.Time `json:"created"`
+		Friends  []*user   `json:"friends,omitempty"`
+		internal string
+		Ignored  string `json:"-"`
+		audit
+	}
+
+	audit struct {
+		Operator string `json:"operator"`
+	}
+
+	createUser struct {
+		Name  string            `json:"name"`
+		Attrs map[string]string `json:"attrs,optional"`
+		Raw   []byte            `json:"raw,omitempty"`
+		Any   any               `json:"any,omitempty"`
+	}
+)
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator("user api", "1.0")
+	g.AddErrors(errors.Entry{Code: 1001, Msg: "user frozen"})
+	g.AddEndpoint(Endpoint{
+		Method:       http.MethodPost,
+		Path:         "/users",
+		OperationID:  "createUser",
+		Request:      createUser{},
+		Response:     user{},
+		Errors:       []int{40000, 40900, 1001},
+		ErrorHandler: true,
+	})
+	g.AddEndpoint(Endpoint{
+		Method:   http.MethodGet,
+		Path:     "/users/:id",
+		Response: &user{},
+		Errors:   []int{40400, 2001},
+		Xml:      true,
+	})
+	g.AddEndpoint(Endpoint{
+		Method:   http.MethodGet,
+		Path:     "/users",
+		Response: xhttp.PageResponse[user]{},
+	})
+	g.AddEndpoint(Endpoint{
+		Method: http.MethodDelete,
+		Path:   "/users/:id",
+	})
+	doc := g.Document()
+
+	schemas := doc.Components.Schemas
+	assert.Equal(t, []string{"code", "msg"}, schemas["BaseResponse"].Required)
+	assert.NotContains(t, schemas["BaseResponse"].Properties, "data")
+
+	userSchema := schemas["user"]
+	assert.ElementsMatch(t, []string{"id", "name", "created", "operator"}, userSchema.Required)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, userSchema.Properties["created"])
+	assert.Equal(t, &XML{Name: "id", Attribute: true}, userSchema.Properties["id"].XML)
+	assert.Equal(t, RefOf("user"), userSchema.Properties["friends"].Items)
+	assert.NotContains(t, userSchema.Properties, "internal")
+	assert.NotContains(t, userSchema.Properties, "Ignored")
+	assert.Contains(t, schemas, "PageResponse_user")
+	assert.Equal(t, RefOf("user"), schemas["BaseResponse_user"].AllOf[1].Properties["data"])
+
+	create := doc.Paths["/users"]["post"]
+	assert.Equal(t, "createUser", create.OperationID)
+	assert.Equal(t, RefOf("createUser"), create.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, &Schema{Type: "string", Format: "byte"}, schemas["createUser"].Properties["raw"])
+	assert.Equal(t, RefOf("BaseResponse_user"), create.Responses["200"].Content["application/json"].Schema)
+	assert.Equal(t, "40000: invalid params", create.Responses["400"].Description)
+	assert.Equal(t, "40900: conflict", create.Responses["409"].Description)
+	assert.Equal(t, "1001: user frozen", create.Responses["default"].Description)
+	assert.Equal(t, []any{40000},
+		create.Responses["400"].Content["application/json"].Schema.AllOf[1].Properties["code"].Enum)
+
+	get := doc.Paths["/users/{id}"]["get"]
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}},
+		get.Parameters)
+	// the errors are responded with http.StatusOK without the error handler
+	assert.Len(t, get.Responses, 1)
+	assert.Equal(t, "ok, or the errors:\n2001\n40400: not found", get.Responses["200"].Description)
+	jsonSchema := get.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, RefOf("BaseResponse_user"), jsonSchema.OneOf[0])
+	assert.Equal(t, []any{2001, 40400}, jsonSchema.OneOf[1].AllOf[1].Properties["code"].Enum)
+	xmlSchema := get.Responses["200"].Content["application/xml"].Schema.OneOf[0]
+	assert.Equal(t, &XML{Name: "xml"}, xmlSchema.XML)
+	assert.Equal(t, RefOf("BaseResponse_user"), xmlSchema.AllOf[0])
+	xmlErrSchema := get.Responses["200"].Content["application/xml"].Schema.OneOf[1]
+	assert.Equal(t, &XML{Name: "xml"}, xmlErrSchema.XML)
+
+	del := doc.Paths["/users/{id}"]["delete"]
+	assert.Equal(t, RefOf("BaseResponse"), del.Responses["200"].Content["application/json"].Schema)
+
+	assert.Contains(t, schemas["ErrorResponse"].AllOf[1].Properties["code"].Enum, 2001)
+	assert.Contains(t, schemas["ErrorResponse"].AllOf[1].Properties["code"].Enum, 50300)
+
+	bs, err := json.Marshal(g)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"openapi":"3.0.3"`)
+}
+
+func TestSchemaName(t *testing.T) {
+	g := NewGenerator("api", "1.0")
+	g.AddEndpoint(Endpoint{Method: http.MethodGet, Path: "/", Response: []xhttp.PageResponse[*user]{}})
+	assert.Contains(t, g.Document().Components.Schemas, "BaseResponse_List_PageResponse_user")
+	assert.Equal(t, "List_int", g.typeName(reflectTypeOf([]int{})))
+	assert.Equal(t, "map", g.typeName(reflectTypeOf(map[string]int{})))
+}
+
+func TestUniqueName(t *testing.T) {
+	g := NewGenerator("api", "1.0")
+	type BaseResponse struct {
+		Name string `json:"name"`
+	}
+	assert.Equal(t, RefOf("BaseResponse2"), g.schemaOf(reflectTypeOf(BaseResponse{})))
+}
+
+func TestEnvelopeName(t *testing.T) {
+	g := NewGenerator("api", "1.0")
+	// declared before shadowing the package level user
+	another := &user{}
+	type user struct {
+		ID int `json:"id"`
+	}
+	g.AddEndpoint(Endpoint{Method: http.MethodGet, Path: "/a", Response: map[string]bool{}})
+	g.AddEndpoint(Endpoint{Method: http.MethodGet, Path: "/b", Response: map[string]string{}})
+	g.AddEndpoint(Endpoint{Method: http.MethodGet, Path: "/c", Response: &user{}})
+	g.AddEndpoint(Endpoint{Method: http.MethodGet, Path: "/d", Response: user{}})
+	g.AddEndpoint(Endpoint{Method: http.MethodGet, Path: "/e", Response: another})
+
+	doc := g.Document()
+	schemas := doc.Components.Schemas
+	assert.Equal(t, &Schema{Type: "boolean"},
+		schemas["BaseResponse_map"].AllOf[1].Properties["data"].AdditionalProperties)
+	assert.Equal(t, &Schema{Type: "string"},
+		schemas["BaseResponse_map2"].AllOf[1].Properties["data"].AdditionalProperties)
+	assert.Equal(t, RefOf("user"), schemas["BaseResponse_user"].AllOf[1].Properties["data"])
+	assert.Equal(t, RefOf("user2"), schemas["BaseResponse_user2"].AllOf[1].Properties["data"])
+	assert.Equal(t, RefOf("BaseResponse_user"), doc.Paths["/d"]["get"].Responses["200"].
+		Content["application/json"].Schema)
+}
+
+func reflectTypeOf(v any) reflect.Type {
+	return reflect.TypeOf(v)
+}
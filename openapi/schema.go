@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJsonType   = reflect.TypeOf(json.RawMessage{})
+	invalidNameRe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+)
+
+// schemaOf returns the schema of t, the named structs are added into the components and referenced.
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawJsonType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if len(t.Name()) == 0 {
+			return g.structSchema(t)
+		}
+		return g.structRef(t)
+	default:
+		// interfaces and the others accept any value
+		return &Schema{}
+	}
+}
+
+func (g *Generator) structRef(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.uniqueName(schemaName(t))
+		g.names[t] = name
+		// registered before building to support the recursive types
+		g.doc.Components.Schemas[name] = &Schema{}
+		*g.doc.Components.Schemas[name] = *g.structSchema(t)
+	}
+
+	return RefOf(name)
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitempty, skip := parseJsonTag(field)
+		if skip {
+			continue
+		}
+
+		// the fields of the untagged embedded structs are promoted as encoding/json does
+		if field.Anonymous && len(field.Tag.Get("json")) == 0 {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := g.structSchema(ft)
+				for k, v := range embedded.Properties {
+					schema.Properties[k] = v
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		prop := g.schemaOf(field.Type)
+		if xmlName, attr := parseXmlTag(field); len(xmlName) > 0 && xmlName != name || attr {
+			prop = withXml(prop, &XML{Name: xmlName, Attribute: attr})
+		}
+		schema.Properties[name] = prop
+		if !omitempty && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// uniqueName returns name, or name with a numeric suffix if it's taken by another type.
+func (g *Generator) uniqueName(name string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		if _, ok := g.doc.Components.Schemas[candidate]; !ok {
+			return candidate
+		}
+		candidate = name + strconv.Itoa(i)
+	}
+}
+
+func parseJsonTag(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if len(name) == 0 {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "optional" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty, false
+}
+
+func parseXmlTag(field reflect.StructField) (name string, attr bool) {
+	tag := field.Tag.Get("xml")
+	if len(tag) == 0 || tag == "-" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "attr" {
+			attr = true
+		}
+	}
+
+	return parts[0], attr
+}
+
+// withXml attaches x to schema, a reference is wrapped by allOf since its siblings are ignored.
+func withXml(schema *Schema, x *XML) *Schema {
+	if len(schema.Ref) > 0 {
+		return &Schema{AllOf: []*Schema{schema}, XML: x}
+	}
+
+	schema.XML = x
+	return schema
+}
+
+// schemaName returns the component name of t, the generic arguments are joined by underscores,
+// like PageResponse_User for PageResponse[pkg.User].
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		args := name[i+1 : len(name)-1]
+		name = name[:i]
+		for _, arg := range strings.Split(args, ",") {
+			if j := strings.LastIndexByte(arg, '.'); j >= 0 {
+				arg = arg[j+1:]
+			}
+			name += "_" + arg
+		}
+	}
+
+	return invalidNameRe.ReplaceAllString(name, "_")
+}
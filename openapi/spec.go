@@ -0,0 +1,91 @@
+package openapi
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+type (
+	// Document is an OpenAPI 3 document, only the parts used by Generator are modeled.
+	Document struct {
+		OpenAPI    string              `json:"openapi"`
+		Info       Info                `json:"info"`
+		Paths      map[string]PathItem `json:"paths"`
+		Components Components          `json:"components"`
+	}
+
+	// Info is the metadata of the API.
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	// PathItem maps the lower-case http methods to the operations of a path.
+	PathItem map[string]*Operation
+
+	// Operation describes an API operation.
+	Operation struct {
+		OperationID string               `json:"operationId,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Parameters  []Parameter          `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+	}
+
+	// Parameter describes an operation parameter.
+	Parameter struct {
+		Name     string  `json:"name"`
+		In       string  `json:"in"`
+		Required bool    `json:"required,omitempty"`
+		Schema   *Schema `json:"schema"`
+	}
+
+	// RequestBody describes the request body of an operation.
+	RequestBody struct {
+		Required bool                 `json:"required,omitempty"`
+		Content  map[string]MediaType `json:"content"`
+	}
+
+	// Response describes a response of an operation.
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	// MediaType describes the schema of a content type.
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	// Components holds the reusable schemas.
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	}
+
+	// Schema is a JSON schema in the OpenAPI dialect.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		AllOf                []*Schema          `json:"allOf,omitempty"`
+		OneOf                []*Schema          `json:"oneOf,omitempty"`
+		Enum                 []any              `json:"enum,omitempty"`
+		Nullable             bool               `json:"nullable,omitempty"`
+		XML                  *XML               `json:"xml,omitempty"`
+	}
+
+	// XML describes the xml representation of a schema.
+	XML struct {
+		Name      string `json:"name,omitempty"`
+		Attribute bool   `json:"attribute,omitempty"`
+		Wrapped   bool   `json:"wrapped,omitempty"`
+	}
+)
+
+// RefOf returns a Schema referencing the component schema name.
+func RefOf(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
@@ -0,0 +1,86 @@
+// Package provider provides time, ID and randomness abstractions.
+// Production code depends on the interfaces and uses the system implementations by default,
+// tests replace them with the controllable fakes in package github.com/zeromicro/x/test,
+// so that the responses containing timestamps or generated IDs are stable.
+package provider
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// SystemClock is the Clock that reads the system time.
+	SystemClock Clock = systemClock{}
+	// UUIDGenerator is the IDGenerator that generates random UUIDs.
+	UUIDGenerator IDGenerator = uuidGenerator{}
+)
+
+type (
+	// Clock tells the current time.
+	Clock interface {
+		Now() time.Time
+	}
+
+	// IDGenerator generates unique IDs.
+	IDGenerator interface {
+		NewID() string
+	}
+
+	// Rand generates pseudo-random numbers, it's safe for concurrent use.
+	Rand interface {
+		Int63() int64
+		Intn(n int) int
+		Float64() float64
+	}
+
+	systemClock struct{}
+
+	uuidGenerator struct{}
+
+	lockedRand struct {
+		lock sync.Mutex
+		r    *rand.Rand
+	}
+)
+
+// NewRand creates a Rand seeded with seed.
+func NewRand(seed int64) Rand {
+	return &lockedRand{
+		r: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// NewSystemRand creates a Rand seeded with the current time.
+func NewSystemRand() Rand {
+	return NewRand(time.Now().UnixNano())
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (uuidGenerator) NewID() string {
+	return uuid.NewString()
+}
+
+func (r *lockedRand) Int63() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.r.Int63()
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.r.Intn(n)
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.r.Float64()
+}
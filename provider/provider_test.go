@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := SystemClock.Now()
+	assert.False(t, now.Before(before))
+}
+
+func TestUUIDGenerator(t *testing.T) {
+	id := UUIDGenerator.NewID()
+	assert.Len(t, id, 36)
+	assert.NotEqual(t, id, UUIDGenerator.NewID())
+}
+
+func TestNewRand(t *testing.T) {
+	r1 := NewRand(1)
+	r2 := NewRand(1)
+	assert.Equal(t, r1.Int63(), r2.Int63())
+	assert.Equal(t, r1.Intn(100), r2.Intn(100))
+	assert.Equal(t, r1.Float64(), r2.Float64())
+
+	v := NewSystemRand().Intn(10)
+	assert.True(t, v >= 0 && v < 10)
+}
@@ -0,0 +1,221 @@
+// Package rpc provides gRPC interceptors which normalize errors between errors.CodeMsg and gRPC status.
+// The server interceptors convert any returned error or panic into a gRPC status with details,
+// and the client interceptors convert the received status carrying a business code back into *errors.CodeMsg,
+// so that the business code survives a full HTTP→RPC→HTTP round trip, for example:
+//
+//	server := zrpc.MustNewServer(c, register)
+//	server.AddUnaryInterceptors(rpc.UnaryServerInterceptor(rpc.WithHideInternalErrors()))
+//
+//	client := zrpc.MustNewClient(c, zrpc.WithUnaryClientInterceptor(rpc.UnaryClientInterceptor()))
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	xerrors "github.com/zeromicro/x/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const internalErrorMsg = "internal error"
+
+type (
+	// Option represents an option for the server interceptors.
+	Option func(*options)
+
+	options struct {
+		hideInternal  bool
+		internalError *xerrors.CodeMsg
+	}
+
+	clientStream struct {
+		grpc.ClientStream
+	}
+)
+
+// WithHideInternalErrors is an option to hide the messages of the errors which are neither
+// errors.CodeMsg nor gRPC status, such as the errors from databases and panics, from external callers.
+// The messages of the errors.CodeMsg which are not safe, see errors.CodeMsg.Safe, are replaced
+// with a generic message, their business codes are kept.
+func WithHideInternalErrors() Option {
+	return func(o *options) {
+		o.hideInternal = true
+	}
+}
+
+// WithInternalError is an option to set the CodeMsg returned instead of the hidden internal errors.
+// By default, a status with codes.Internal is returned.
+func WithInternalError(cm *xerrors.CodeMsg) Option {
+	return func(o *options) {
+		o.internalError = cm
+	}
+}
+
+// UnaryServerInterceptor returns a unary server interceptor which normalizes the returned errors
+// and recovers the panics into gRPC status.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = o.recoverError(ctx, info.FullMethod, p)
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		return resp, o.toStatusError(ctx, info.FullMethod, err)
+	}
+}
+
+// StreamServerInterceptor returns a stream server interceptor which normalizes the returned errors
+// and recovers the panics into gRPC status.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts...)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) (err error) {
+		ctx := ss.Context()
+		defer func() {
+			if p := recover(); p != nil {
+				err = o.recoverError(ctx, info.FullMethod, p)
+			}
+		}()
+
+		return o.toStatusError(ctx, info.FullMethod, handler(srv, ss))
+	}
+}
+
+// UnaryClientInterceptor returns a unary client interceptor which converts the received
+// gRPC status errors carrying business codes into *errors.CodeMsg.
+// The other errors are returned untouched, so that the outer interceptors, like breakers and retries,
+// still see their gRPC codes, such as codes.Unavailable and codes.DeadlineExceeded.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return toCodeMsg(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor returns a stream client interceptor which converts the received
+// gRPC status errors carrying business codes into *errors.CodeMsg, the other errors are returned untouched.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, toCodeMsg(err)
+		}
+
+		return clientStream{ClientStream: cs}, nil
+	}
+}
+
+func (s clientStream) SendMsg(m any) error {
+	return toCodeMsg(s.ClientStream.SendMsg(m))
+}
+
+func (s clientStream) RecvMsg(m any) error {
+	return toCodeMsg(s.ClientStream.RecvMsg(m))
+}
+
+func (s clientStream) CloseSend() error {
+	return toCodeMsg(s.ClientStream.CloseSend())
+}
+
+func newOptions(opts ...Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) toStatusError(ctx context.Context, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var cm *xerrors.CodeMsg
+	if errors.As(err, &cm) {
+		logCodeMsg(ctx, method, cm, err)
+		if o.hideInternal && !cm.Safe() {
+			// keep the business code and the metadata, but not the message which may carry internal details
+			redacted := *cm
+			redacted.Msg = internalErrorMsg
+			return redacted.GRPCStatus().Err()
+		}
+
+		return cm.GRPCStatus().Err()
+	}
+
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		logx.WithContext(ctx).Errorf("%s: %+v", method, err)
+		return se.GRPCStatus().Err()
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	logx.WithContext(ctx).Errorf("%s: %+v", method, err)
+	if o.hideInternal {
+		return o.internalStatusError()
+	}
+
+	return status.Error(codes.Unknown, err.Error())
+}
+
+func (o options) recoverError(ctx context.Context, method string, p any) error {
+	logx.WithContext(ctx).Errorf("%s: panic: %+v\n%s", method, p, debug.Stack())
+	if o.hideInternal {
+		return o.internalStatusError()
+	}
+
+	return status.Error(codes.Internal, fmt.Sprintf("panic: %v", p))
+}
+
+func (o options) internalStatusError() error {
+	if o.internalError != nil {
+		return o.internalError.GRPCStatus().Err()
+	}
+
+	return status.Error(codes.Internal, internalErrorMsg)
+}
+
+// logCodeMsg logs err at the level of the severity of cm, the errors without severity are logged
+// at error level if they are caused by the server or its dependencies, otherwise they are not logged.
+func logCodeMsg(ctx context.Context, method string, cm *xerrors.CodeMsg, err error) {
+	logger := logx.WithContext(ctx)
+	switch cm.Severity() {
+	case xerrors.SeverityDebug:
+		logger.Debugf("%s: %+v", method, err)
+	case xerrors.SeverityInfo, xerrors.SeverityWarning:
+		logger.Infof("%s: %+v", method, err)
+	case xerrors.SeverityError, xerrors.SeverityCritical:
+		logger.Errorf("%s: %+v", method, err)
+	default:
+		if cm.Category() == xerrors.CategoryServer || cm.Category() == xerrors.CategoryDependency {
+			logger.Errorf("%s: %+v", method, err)
+		}
+	}
+}
+
+func toCodeMsg(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok || !xerrors.HasBusinessCode(st) {
+		return err
+	}
+
+	return xerrors.FromStatus(st)
+}
@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/logx"
+	xerrors "github.com/zeromicro/x/errors"
+	"github.com/zeromicro/x/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const method = "/user.User/Get"
+
+type statusResult struct {
+	Code codes.Code
+	Msg  string
+	// BusinessCode is the code restored by the client side.
+	BusinessCode int
+}
+
+func TestMain(m *testing.M) {
+	logx.Disable()
+	m.Run()
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	executor := test.NewExecutor[error, statusResult]()
+	executor.Add([]test.Data[error, statusResult]{
+		{
+			Name: "nil",
+		},
+		{
+			Name:  "code-msg",
+			Input: fmt.Errorf("wrapped: %w", xerrors.New(1001, "test")),
+			Want:  statusResult{Code: codes.Unknown, Msg: "test", BusinessCode: 1001},
+		},
+		{
+			Name:  "status",
+			Input: status.Error(codes.NotFound, "not found"),
+			Want:  statusResult{Code: codes.NotFound, Msg: "not found", BusinessCode: int(codes.NotFound)},
+		},
+		{
+			Name:  "context",
+			Input: fmt.Errorf("wrapped: %w", context.DeadlineExceeded),
+			Want: statusResult{Code: codes.DeadlineExceeded, Msg: "wrapped: context deadline exceeded",
+				BusinessCode: int(codes.DeadlineExceeded)},
+		},
+		{
+			Name:  "error",
+			Input: errors.New("dial tcp 10.0.0.1:3306"),
+			Want:  statusResult{Code: codes.Unknown, Msg: "dial tcp 10.0.0.1:3306", BusinessCode: int(codes.Unknown)},
+		},
+	}...)
+	executor.Run(t, func(err error) statusResult {
+		return invokeUnary(UnaryServerInterceptor(), func(ctx context.Context, req any) (any, error) {
+			return nil, err
+		})
+	})
+}
+
+func TestUnaryServerInterceptorHideInternal(t *testing.T) {
+	interceptor := UnaryServerInterceptor(WithHideInternalErrors())
+	result := invokeUnary(interceptor, func(ctx context.Context, req any) (any, error) {
+		return nil, errors.New("dial tcp 10.0.0.1:3306")
+	})
+	assert.Equal(t, statusResult{Code: codes.Internal, Msg: internalErrorMsg, BusinessCode: int(codes.Internal)},
+		result)
+
+	result = invokeUnary(interceptor, func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+	assert.Equal(t, statusResult{Code: codes.Internal, Msg: internalErrorMsg, BusinessCode: int(codes.Internal)},
+		result)
+
+	result = invokeUnary(interceptor, func(ctx context.Context, req any) (any, error) {
+		return nil, xerrors.New(1002, "dial tcp 10.0.0.1:3306", xerrors.WithCategory(xerrors.CategoryDependency))
+	})
+	assert.Equal(t, statusResult{Code: codes.Unknown, Msg: internalErrorMsg, BusinessCode: 1002}, result)
+
+	result = invokeUnary(interceptor, func(ctx context.Context, req any) (any, error) {
+		return nil, xerrors.New(1003, "invalid name", xerrors.WithCategory(xerrors.CategoryClient))
+	})
+	assert.Equal(t, statusResult{Code: codes.Unknown, Msg: "invalid name", BusinessCode: 1003}, result)
+
+	interceptor = UnaryServerInterceptor(WithHideInternalErrors(),
+		WithInternalError(&xerrors.CodeMsg{Code: 500, Msg: "server busy"}))
+	result = invokeUnary(interceptor, func(ctx context.Context, req any) (any, error) {
+		return nil, errors.New("dial tcp 10.0.0.1:3306")
+	})
+	assert.Equal(t, statusResult{Code: codes.Unknown, Msg: "server busy", BusinessCode: 500}, result)
+}
+
+func TestUnaryServerInterceptorPanic(t *testing.T) {
+	result := invokeUnary(UnaryServerInterceptor(), func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+	assert.Equal(t, statusResult{Code: codes.Internal, Msg: "panic: boom", BusinessCode: int(codes.Internal)}, result)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: method}
+	err := interceptor(nil, mockServerStream{}, info, func(srv any, stream grpc.ServerStream) error {
+		return xerrors.New(1001, "test")
+	})
+	assert.Equal(t, &xerrors.CodeMsg{Code: 1001, Msg: "test"}, xerrors.FromStatus(status.Convert(err)))
+
+	err = interceptor(nil, mockServerStream{}, info, func(srv any, stream grpc.ServerStream) error {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	interceptor := UnaryClientInterceptor()
+	err := interceptor(context.Background(), method, nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return (&xerrors.CodeMsg{Code: 1001, Msg: "test"}).GRPCStatus().Err()
+		})
+	assert.Equal(t, &xerrors.CodeMsg{Code: 1001, Msg: "test"}, err)
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	err = interceptor(context.Background(), method, nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return unavailable
+		})
+	assert.Equal(t, unavailable, err)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	dummyErr := errors.New("dummy")
+	err = interceptor(context.Background(), method, nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return dummyErr
+		})
+	assert.Equal(t, dummyErr, err)
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	interceptor := StreamClientInterceptor()
+	notFound := status.Error(codes.NotFound, "not found")
+	_, err := interceptor(context.Background(), nil, nil, method,
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, notFound
+		})
+	assert.Equal(t, notFound, err)
+
+	cs, err := interceptor(context.Background(), nil, nil, method,
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return mockClientStream{err: notFound}, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, notFound, cs.SendMsg(nil))
+	assert.Equal(t, notFound, cs.CloseSend())
+
+	cs, err = interceptor(context.Background(), nil, nil, method,
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return mockClientStream{err: io.EOF}, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, io.EOF, cs.RecvMsg(nil))
+}
+
+func invokeUnary(interceptor grpc.UnaryServerInterceptor, handler grpc.UnaryHandler) statusResult {
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	if err == nil {
+		return statusResult{}
+	}
+
+	st := status.Convert(err)
+	var result statusResult
+	result.Code = st.Code()
+	result.Msg = st.Message()
+	result.BusinessCode = xerrors.FromStatus(st).Code
+	return result
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+}
+
+func (mockServerStream) Context() context.Context {
+	return context.Background()
+}
+
+type mockClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s mockClientStream) SendMsg(any) error {
+	return s.err
+}
+
+func (s mockClientStream) RecvMsg(any) error {
+	return s.err
+}
+
+func (s mockClientStream) CloseSend() error {
+	return s.err
+}
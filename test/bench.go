@@ -0,0 +1,194 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+// BenchOption represents an option for Executor.Bench.
+type BenchOption func(*benchConfig)
+
+type benchConfig struct {
+	baseline  string
+	threshold float64
+	update    bool
+}
+
+// BenchResult is the measured result of a benchmark case.
+type BenchResult struct {
+	NsPerOp     float64 `json:"nsPerOp"`
+	AllocsPerOp float64 `json:"allocsPerOp"`
+	BytesPerOp  float64 `json:"bytesPerOp"`
+}
+
+// WithBaseline is an option to compare the results with the baseline file at path,
+// a case fails if its ns/op or allocs/op grows by more than threshold, e.g. 0.1 means 10%.
+// The baseline file is created with the current results if it doesn't exist,
+// otherwise, the cases missing from it fail until it's updated by WithBaselineUpdate.
+func WithBaseline(path string, threshold float64) BenchOption {
+	return func(c *benchConfig) {
+		c.baseline = path
+		c.threshold = threshold
+	}
+}
+
+// WithBaselineUpdate is an option to overwrite the baseline file with the current results.
+func WithBaselineUpdate() BenchOption {
+	return func(c *benchConfig) {
+		c.update = true
+	}
+}
+
+// Bench executes the test cases as sub-benchmarks with allocation reporting,
+// generic type T is the input type, generic type Y is the want type.
+func (e *Executor[T, Y]) Bench(b *testing.B, do func(T) Y, opts ...BenchOption) {
+	if do == nil {
+		panic("execution body is nil")
+	}
+
+	var c benchConfig
+	for _, o := range opts {
+		o(&c)
+	}
+
+	results := make(map[string]BenchResult)
+	for _, v := range e.list {
+		name, input := v.Name, v.Input
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			results[name] = measure(b, func() {
+				do(input)
+			})
+		})
+	}
+
+	if len(c.baseline) == 0 {
+		return
+	}
+
+	baseline, err := loadBaseline(c.baseline)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			b.Fatal(err)
+		}
+		c.update = true
+	}
+
+	for _, msg := range compareBaseline(baseline, results, c.threshold, c.update) {
+		b.Error(msg)
+	}
+
+	if !c.update {
+		return
+	}
+
+	for name, result := range results {
+		baseline[name] = result
+	}
+	if err := saveBaseline(c.baseline, baseline); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func measure(b *testing.B, fn func()) BenchResult {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		fn()
+	}
+	elapsed := time.Since(start)
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+
+	n := float64(b.N)
+	return BenchResult{
+		NsPerOp:     float64(elapsed.Nanoseconds()) / n,
+		AllocsPerOp: float64(after.Mallocs-before.Mallocs) / n,
+		BytesPerOp:  float64(after.TotalAlloc-before.TotalAlloc) / n,
+	}
+}
+
+// compareBaseline compares results with baseline, the cases missing from baseline fail
+// unless baseline is being updated, so that the regressions can't hide behind a stale baseline.
+func compareBaseline(baseline, results map[string]BenchResult, threshold float64, update bool) []string {
+	var msgs []string
+	for _, name := range sortedNames(results) {
+		base, ok := baseline[name]
+		if !ok {
+			if !update {
+				msgs = append(msgs, fmt.Sprintf("%s: missing from the baseline, "+
+					"record it with WithBaselineUpdate", name))
+			}
+			continue
+		}
+		for _, msg := range compareBench(base, results[name], threshold) {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", name, msg))
+		}
+	}
+
+	return msgs
+}
+
+func compareBench(base, current BenchResult, threshold float64) []string {
+	var msgs []string
+	if regressed(base.NsPerOp, current.NsPerOp, threshold) {
+		msgs = append(msgs, formatRegression("ns/op", base.NsPerOp, current.NsPerOp))
+	}
+	if regressed(base.AllocsPerOp, current.AllocsPerOp, threshold) {
+		msgs = append(msgs, formatRegression("allocs/op", base.AllocsPerOp, current.AllocsPerOp))
+	}
+
+	return msgs
+}
+
+func regressed(base, current, threshold float64) bool {
+	if base == 0 {
+		return current >= 1
+	}
+
+	return current > base*(1+threshold)
+}
+
+func formatRegression(unit string, base, current float64) string {
+	return fmt.Sprintf("%.2f %s regressed from baseline %.2f %s", current, unit, base, unit)
+}
+
+func loadBaseline(path string) (map[string]BenchResult, error) {
+	baseline := make(map[string]BenchResult)
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return baseline, err
+	}
+
+	if err := json.Unmarshal(bs, &baseline); err != nil {
+		return baseline, err
+	}
+
+	return baseline, nil
+}
+
+func saveBaseline(path string, baseline map[string]BenchResult) error {
+	bs, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, bs, 0o644)
+}
+
+func sortedNames(results map[string]BenchResult) []string {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBenchExecutor() *Executor[string, string] {
+	executor := NewExecutor[string, string]()
+	executor.Add([]Data[string, string]{
+		{
+			Name:  "snake_case",
+			Input: "A_B_C",
+		},
+		{
+			Name:  "camel_case",
+			Input: "AaBbCc",
+		},
+	}...)
+	return executor
+}
+
+func BenchmarkExecutor_Bench(b *testing.B) {
+	newBenchExecutor().Bench(b, strings.ToLower)
+}
+
+func TestExecutor_BenchBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	testing.Benchmark(func(b *testing.B) {
+		newBenchExecutor().Bench(b, strings.ToLower, WithBaseline(path, 0.1))
+	})
+
+	baseline, err := loadBaseline(path)
+	assert.NoError(t, err)
+	assert.Len(t, baseline, 2)
+}
+
+func TestLoadBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	_, err := loadBaseline(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = loadBaseline(path)
+	assert.Error(t, err)
+
+	assert.NoError(t, saveBaseline(path, map[string]BenchResult{"case": {NsPerOp: 1}}))
+	baseline, err := loadBaseline(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]BenchResult{"case": {NsPerOp: 1}}, baseline)
+}
+
+func TestCompareBench(t *testing.T) {
+	base := BenchResult{NsPerOp: 100, AllocsPerOp: 2}
+	assert.Empty(t, compareBench(base, BenchResult{NsPerOp: 105, AllocsPerOp: 2}, 0.1))
+	assert.Len(t, compareBench(base, BenchResult{NsPerOp: 120, AllocsPerOp: 2}, 0.1), 1)
+	assert.Len(t, compareBench(base, BenchResult{NsPerOp: 120, AllocsPerOp: 3}, 0.1), 2)
+	assert.Len(t, compareBench(BenchResult{}, BenchResult{AllocsPerOp: 1}, 0.1), 1)
+}
+
+func TestCompareBaseline(t *testing.T) {
+	baseline := map[string]BenchResult{"old": {NsPerOp: 100}}
+	results := map[string]BenchResult{
+		"old": {NsPerOp: 120},
+		"new": {NsPerOp: 10},
+	}
+	assert.Equal(t, []string{
+		"new: missing from the baseline, record it with WithBaselineUpdate",
+		"old: 120.00 ns/op regressed from baseline 100.00 ns/op",
+	}, compareBaseline(baseline, results, 0.1, false))
+	assert.Equal(t, []string{"old: 120.00 ns/op regressed from baseline 100.00 ns/op"},
+		compareBaseline(baseline, results, 0.1, true))
+}
@@ -0,0 +1,512 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	maxFormatLen = 64
+	rootPath     = "$"
+)
+
+// Missing represents a value absent from a JSON object, matchers receive it as want or got.
+var Missing = missing{}
+
+type (
+	missing struct{}
+
+	// Matcher compares the expected value and the actual value at a path,
+	// both values are normalized as the decoded JSON values, numbers are json.Number.
+	Matcher interface {
+		Match(want, got any) error
+	}
+
+	// MatcherFunc is an adapter to allow the use of ordinary functions as Matcher.
+	MatcherFunc func(want, got any) error
+
+	// Rule applies a Matcher to the values at the paths matching a pattern.
+	Rule struct {
+		pattern []string
+		matcher Matcher
+		expect  bool
+	}
+
+	ignoreMatcher struct{}
+
+	subsetMatcher struct{}
+
+	unorderedMatcher struct{}
+
+	differ struct {
+		rules []Rule
+		diffs []string
+	}
+)
+
+// Match calls fn(want, got).
+func (fn MatcherFunc) Match(want, got any) error {
+	return fn(want, got)
+}
+
+// Field creates a Rule which applies m to the values at path. The path is like data.items[3].price,
+// a * segment matches any object key, and [*] matches any array index, like data.items[*].id,
+// the leading $ is optional, and $ alone represents the root value.
+func Field(path string, m Matcher) Rule {
+	return Rule{
+		pattern: parsePath(path),
+		matcher: m,
+	}
+}
+
+// Expect creates a Rule which asserts the actual values at path with m regardless of the want value,
+// the path is the same as the one of Field. It fails if no value is found at path, for example:
+//
+//	Expect("data.items[3].price", Equal(10))
+func Expect(path string, m Matcher) Rule {
+	return Rule{
+		pattern: parsePath(path),
+		matcher: m,
+		expect:  true,
+	}
+}
+
+// WithMatchers is an option to compare the want and the actual values structurally with rules,
+// generic type T is the input type, generic type Y is the output type.
+// A failure prints a path-level diff like data.items[3].price: want 10 got 12.
+func WithMatchers[T, Y any](rules ...Rule) Option[T, Y] {
+	return WithComparison[T, Y](func(t *testing.T, expected, actual Y) {
+		t.Helper()
+		diffs, err := Diff(expected, actual, rules...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diffs) > 0 {
+			t.Errorf("values are not matched:\n%s", strings.Join(diffs, "\n"))
+		}
+	})
+}
+
+// Diff compares want and got as JSON values with rules, and returns the path-level differences.
+func Diff(want, got any, rules ...Rule) ([]string, error) {
+	wantValue, err := normalize(want)
+	if err != nil {
+		return nil, err
+	}
+	gotValue, err := normalize(got)
+	if err != nil {
+		return nil, err
+	}
+
+	var d differ
+	var expects []Rule
+	for _, rule := range rules {
+		if rule.expect {
+			expects = append(expects, rule)
+		} else {
+			d.rules = append(d.rules, rule)
+		}
+	}
+
+	d.diff(nil, wantValue, gotValue, false)
+	for _, rule := range expects {
+		d.expect(rule, gotValue)
+	}
+
+	return d.diffs, nil
+}
+
+// Ignore creates a Matcher which matches any value.
+func Ignore() Matcher {
+	return ignoreMatcher{}
+}
+
+// Subset creates a Matcher which matches if the actual object contains all the keys of the want object,
+// the extra keys are ignored recursively.
+func Subset() Matcher {
+	return subsetMatcher{}
+}
+
+// Unordered creates a Matcher which matches the arrays with the same elements in any order.
+func Unordered() Matcher {
+	return unorderedMatcher{}
+}
+
+// Regex creates a Matcher which matches the strings matching pattern, the want value is ignored.
+func Regex(pattern string) Matcher {
+	re := regexp.MustCompile(pattern)
+	return MatcherFunc(func(_, got any) error {
+		s, ok := got.(string)
+		if !ok || !re.MatchString(s) {
+			return fmt.Errorf("want match %q got %s", pattern, format(got))
+		}
+
+		return nil
+	})
+}
+
+// Approx creates a Matcher which matches the numbers within epsilon of the want value.
+func Approx(epsilon float64) Matcher {
+	return MatcherFunc(func(want, got any) error {
+		w, wok := toFloat(want)
+		g, gok := toFloat(got)
+		if !wok || !gok || math.Abs(w-g) > epsilon {
+			return fmt.Errorf("want %s±%v got %s", format(want), epsilon, format(got))
+		}
+
+		return nil
+	})
+}
+
+// Equal creates a Matcher which matches the values equal to v, the want value is ignored.
+// It's useful for asserting a single path, like Expect("data.items[3].price", Equal(10)).
+func Equal(v any) Matcher {
+	return MatcherFunc(func(_, got any) error {
+		expected, err := normalize(v)
+		if err != nil {
+			return err
+		}
+
+		d := differ{}
+		d.diff(nil, expected, got, false)
+		if len(d.diffs) > 0 {
+			return fmt.Errorf("want %s got %s", format(expected), format(got))
+		}
+
+		return nil
+	})
+}
+
+func (ignoreMatcher) Match(_, _ any) error {
+	return nil
+}
+
+func (subsetMatcher) Match(want, got any) error {
+	d := differ{}
+	d.diff(nil, want, got, true)
+	return diffsError(d.diffs)
+}
+
+func (unorderedMatcher) Match(want, got any) error {
+	d := differ{}
+	d.diffUnordered(nil, want, got, false)
+	return diffsError(d.diffs)
+}
+
+func (d *differ) diff(path []string, want, got any, subset bool) {
+	if m, ok := d.match(path); ok {
+		switch m.(type) {
+		case subsetMatcher:
+			d.diffValue(path, want, got, true)
+		case unorderedMatcher:
+			d.diffUnordered(path, want, got, subset)
+		default:
+			if err := m.Match(want, got); err != nil {
+				d.report(path, err.Error())
+			}
+		}
+		return
+	}
+
+	d.diffValue(path, want, got, subset)
+}
+
+func (d *differ) diffValue(path []string, want, got any, subset bool) {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			d.mismatch(path, want, got)
+			return
+		}
+
+		for _, key := range unionKeys(w, g, subset) {
+			wv, wok := w[key]
+			if !wok {
+				wv = Missing
+			}
+			gv, gok := g[key]
+			if !gok {
+				gv = Missing
+			}
+			d.diff(appendPath(path, key), wv, gv, subset)
+		}
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			d.mismatch(path, want, got)
+			return
+		}
+
+		if len(w) != len(g) && !subset {
+			d.report(path, fmt.Sprintf("want %d items got %d", len(w), len(g)))
+			return
+		}
+
+		for i := range w {
+			var gv any = Missing
+			if i < len(g) {
+				gv = g[i]
+			}
+			d.diff(appendPath(path, indexSegment(i)), w[i], gv, subset)
+		}
+	case json.Number:
+		if !numberEqual(w, got) {
+			d.mismatch(path, want, got)
+		}
+	default:
+		if want != got {
+			d.mismatch(path, want, got)
+		}
+	}
+}
+
+func (d *differ) diffUnordered(path []string, want, got any, subset bool) {
+	w, wok := want.([]any)
+	g, gok := got.([]any)
+	if !wok || !gok {
+		d.mismatch(path, want, got)
+		return
+	}
+	if len(w) != len(g) && !subset {
+		d.report(path, fmt.Sprintf("want %d items got %d", len(w), len(g)))
+		return
+	}
+
+	used := make([]bool, len(g))
+	for i, wv := range w {
+		elemPath := appendPath(path, indexSegment(i))
+		var found bool
+		for j, gv := range g {
+			if used[j] {
+				continue
+			}
+
+			sub := differ{rules: d.rules}
+			sub.diff(elemPath, wv, gv, subset)
+			if len(sub.diffs) == 0 {
+				used[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			d.report(elemPath, fmt.Sprintf("want %s got <not found>", format(wv)))
+		}
+	}
+}
+
+func (d *differ) expect(rule Rule, got any) {
+	var found bool
+	walk(nil, got, func(path []string, v any) {
+		if !matchPath(rule.pattern, path) {
+			return
+		}
+
+		found = true
+		if err := rule.matcher.Match(Missing, v); err != nil {
+			d.report(path, err.Error())
+		}
+	})
+	if !found {
+		d.report(rule.pattern, "want a value got <missing>")
+	}
+}
+
+func (d *differ) match(path []string) (Matcher, bool) {
+	for i := len(d.rules) - 1; i >= 0; i-- {
+		if matchPath(d.rules[i].pattern, path) {
+			return d.rules[i].matcher, true
+		}
+	}
+
+	return nil, false
+}
+
+func (d *differ) mismatch(path []string, want, got any) {
+	d.report(path, fmt.Sprintf("want %s got %s", format(want), format(got)))
+}
+
+func (d *differ) report(path []string, msg string) {
+	d.diffs = append(d.diffs, formatPath(path)+": "+msg)
+}
+
+func walk(path []string, v any, fn func(path []string, v any)) {
+	fn(path, v)
+	switch value := v.(type) {
+	case map[string]any:
+		for key, child := range value {
+			walk(appendPath(path, key), child, fn)
+		}
+	case []any:
+		for i, child := range value {
+			walk(appendPath(path, indexSegment(i)), child, fn)
+		}
+	}
+}
+
+func diffsError(diffs []string) error {
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s", strings.Join(diffs, "; "))
+}
+
+func normalize(v any) (any, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func numberEqual(want json.Number, got any) bool {
+	g, ok := got.(json.Number)
+	if !ok {
+		return false
+	}
+	if want == g {
+		return true
+	}
+
+	wf, werr := want.Float64()
+	gf, gerr := g.Float64()
+	return werr == nil && gerr == nil && wf == gf
+}
+
+func toFloat(v any) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func unionKeys(want, got map[string]any, subset bool) []string {
+	keys := make([]string, 0, len(want)+len(got))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	if !subset {
+		for key := range got {
+			if _, ok := want[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func parsePath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, rootPath), ".")
+	if len(path) == 0 {
+		return nil
+	}
+
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for len(part) > 0 {
+			i := strings.IndexByte(part, '[')
+			switch {
+			case i < 0:
+				segments = append(segments, part)
+				part = ""
+			case i > 0:
+				segments = append(segments, part[:i])
+				part = part[i:]
+			default:
+				end := strings.IndexByte(part, ']')
+				if end < 0 {
+					segments = append(segments, part)
+					part = ""
+					continue
+				}
+				segments = append(segments, part[:end+1])
+				part = part[end+1:]
+			}
+		}
+	}
+
+	return segments
+}
+
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+
+	for i, p := range pattern {
+		switch {
+		case p == path[i]:
+		case p == "*" && !isIndex(path[i]):
+		case p == "[*]" && isIndex(path[i]):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func appendPath(path []string, segment string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), segment)
+}
+
+func indexSegment(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+func isIndex(segment string) bool {
+	return strings.HasPrefix(segment, "[")
+}
+
+func formatPath(path []string) string {
+	if len(path) == 0 {
+		return rootPath
+	}
+
+	var builder strings.Builder
+	for i, segment := range path {
+		if i > 0 && !isIndex(segment) {
+			builder.WriteByte('.')
+		}
+		builder.WriteString(segment)
+	}
+
+	return builder.String()
+}
+
+func format(v any) string {
+	if _, ok := v.(missing); ok {
+		return "<missing>"
+	}
+
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(bs) > maxFormatLen {
+		return string(bs[:maxFormatLen]) + "..."
+	}
+
+	return string(bs)
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	ID    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+type order struct {
+	Name  string `json:"name"`
+	Items []item `json:"items"`
+}
+
+type diffInput struct {
+	want  any
+	got   any
+	rules []Rule
+}
+
+func TestDiff(t *testing.T) {
+	executor := NewExecutor[diffInput, []string]()
+	executor.Add([]Data[diffInput, []string]{
+		{
+			Name: "equal",
+			Input: diffInput{
+				want: order{Name: "a", Items: []item{{ID: "1", Price: 10}}},
+				got:  map[string]any{"name": "a", "items": []any{map[string]any{"id": "1", "price": 10.0}}},
+			},
+		},
+		{
+			Name: "price",
+			Input: diffInput{
+				want: order{Items: []item{{ID: "1", Price: 10}}},
+				got:  order{Items: []item{{ID: "1", Price: 12}}},
+			},
+			Want: []string{"items[0].price: want 10 got 12"},
+		},
+		{
+			Name: "missing-and-extra",
+			Input: diffInput{
+				want: map[string]any{"name": "a"},
+				got:  map[string]any{"id": 1},
+			},
+			Want: []string{"id: want <missing> got 1", `name: want "a" got <missing>`},
+		},
+		{
+			Name: "length",
+			Input: diffInput{
+				want: []int{1, 2},
+				got:  []int{1},
+			},
+			Want: []string{"$: want 2 items got 1"},
+		},
+		{
+			Name: "type",
+			Input: diffInput{
+				want: map[string]any{"a": []int{1}},
+				got:  map[string]any{"a": "1"},
+			},
+			Want: []string{`a: want [1] got "1"`},
+		},
+		{
+			Name: "ignore",
+			Input: diffInput{
+				want:  order{Name: "a", Items: []item{{ID: "1"}, {ID: "2"}}},
+				got:   order{Name: "b", Items: []item{{ID: "3"}, {ID: "4"}}},
+				rules: []Rule{Field("name", Ignore()), Field("items[*].id", Ignore())},
+			},
+		},
+		{
+			Name: "regex",
+			Input: diffInput{
+				want:  item{},
+				got:   item{ID: "abc-123"},
+				rules: []Rule{Field("id", Regex(`^abc-\d+$`))},
+			},
+		},
+		{
+			Name: "regex-mismatch",
+			Input: diffInput{
+				want:  item{},
+				got:   item{ID: "abc"},
+				rules: []Rule{Field("id", Regex(`^\d+$`))},
+			},
+			Want: []string{`id: want match "^\\d+$" got "abc"`},
+		},
+		{
+			Name: "approx",
+			Input: diffInput{
+				want:  item{Price: 10},
+				got:   item{Price: 10.001},
+				rules: []Rule{Field("price", Approx(0.01))},
+			},
+		},
+		{
+			Name: "approx-mismatch",
+			Input: diffInput{
+				want:  item{Price: 10},
+				got:   item{Price: 11},
+				rules: []Rule{Field("price", Approx(0.01))},
+			},
+			Want: []string{"price: want 10±0.01 got 11"},
+		},
+		{
+			Name: "unordered",
+			Input: diffInput{
+				want:  order{Items: []item{{ID: "1"}, {ID: "2"}}},
+				got:   order{Items: []item{{ID: "2"}, {ID: "1"}}},
+				rules: []Rule{Field("items", Unordered())},
+			},
+		},
+		{
+			Name: "unordered-mismatch",
+			Input: diffInput{
+				want:  order{Items: []item{{ID: "1"}, {ID: "2"}}},
+				got:   order{Items: []item{{ID: "2"}, {ID: "3"}}},
+				rules: []Rule{Field("items", Unordered())},
+			},
+			Want: []string{`items[0]: want {"id":"1","price":0} got <not found>`},
+		},
+		{
+			Name: "subset",
+			Input: diffInput{
+				want:  map[string]any{"items": []any{map[string]any{"id": "1"}}},
+				got:   order{Name: "a", Items: []item{{ID: "1", Price: 1}, {ID: "2"}}},
+				rules: []Rule{Field("$", Subset())},
+			},
+		},
+		{
+			Name: "subset-mismatch",
+			Input: diffInput{
+				want:  map[string]any{"name": "b"},
+				got:   order{Name: "a"},
+				rules: []Rule{Field("$", Subset())},
+			},
+			Want: []string{`name: want "b" got "a"`},
+		},
+		{
+			Name: "json-path",
+			Input: diffInput{
+				got:   order{Items: []item{{ID: "1", Price: 10}}},
+				rules: []Rule{Field("$", Ignore()), Expect("items[*].price", Equal(10))},
+			},
+		},
+		{
+			Name: "json-path-mismatch",
+			Input: diffInput{
+				got:   order{Items: []item{{ID: "1", Price: 10}}},
+				rules: []Rule{Field("$", Ignore()), Expect("items[0].price", Equal(12))},
+			},
+			Want: []string{"items[0].price: want 12 got 10"},
+		},
+		{
+			Name: "json-path-missing",
+			Input: diffInput{
+				got:   order{},
+				rules: []Rule{Field("$", Ignore()), Expect("items[0].price", Equal(12))},
+			},
+			Want: []string{"items[0].price: want a value got <missing>"},
+		},
+	}...)
+	executor.Run(t, func(in diffInput) []string {
+		diffs, err := Diff(in.want, in.got, in.rules...)
+		assert.NoError(t, err)
+		return diffs
+	})
+}
+
+func TestDiffMarshalFailed(t *testing.T) {
+	_, err := Diff(complex(0, 0), 1)
+	assert.Error(t, err)
+	_, err = Diff(1, complex(0, 0))
+	assert.Error(t, err)
+}
+
+func TestWithMatchers(t *testing.T) {
+	executor := NewExecutor[string, item](WithMatchers[string, item](Field("id", Regex(`^id-`))))
+	executor.Add(Data[string, item]{
+		Name:  "regex",
+		Input: "1",
+		Want:  item{Price: 1},
+	})
+	executor.Run(t, func(s string) item {
+		return item{ID: "id-" + s, Price: 1}
+	})
+}
+
+func TestParsePath(t *testing.T) {
+	assert.Equal(t, []string{"data", "items", "[3]", "price"}, parsePath("data.items[3].price"))
+	assert.Equal(t, []string{"[*]", "[0]"}, parsePath("[*][0]"))
+	assert.Equal(t, []string{"a", "[0"}, parsePath("a[0"))
+	assert.Empty(t, parsePath("$"))
+	assert.Equal(t, []string{"a"}, parsePath("$.a"))
+	assert.Equal(t, "data.items[3].price", formatPath(parsePath("data.items[3].price")))
+}
@@ -0,0 +1,23 @@
+// Package mock provides in-process fake dependency servers for tests.
+// The servers serve scripted responses and record the received calls for assertions,
+// they never leave the machine, so they work offline in CI:
+// the HTTP server is an httptest.Server listening on a loopback TCP port,
+// and the gRPC server listens on an in-memory bufconn listener, for example:
+//
+//	server := mock.NewHTTPServer()
+//	defer server.Close()
+//	server.On(http.MethodGet, "/users/1", mock.HTTPResponse{Data: user{Name: "anyone"}})
+//
+// then a request to server.URL+"/users/1" receives:
+//
+//	{"code":0,"msg":"ok","data":{"name":"anyone"}}
+//
+// and a gRPC dependency can be faked like this:
+//
+//	server := mock.NewGRPCServer()
+//	defer server.Close()
+//	server.On("/user.User/Get", mock.GRPCResponse{Status: status.New(codes.NotFound, "not found")})
+//	conn, err := server.Dial(ctx)
+//
+// The gRPC server only supports unary calls, the streaming calls receive the first message only.
+package mock
@@ -0,0 +1,183 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const bufSize = 1 << 20
+
+// GRPCResponse is a scripted response of GRPCServer.
+type GRPCResponse struct {
+	// Reply represents the reply message, it's ignored if Status is not nil,
+	// an empty message is replied if both are nil.
+	Reply proto.Message
+	// Status represents the returned status, it may carry details.
+	Status *status.Status
+}
+
+// GRPCCall is a request received by GRPCServer.
+type GRPCCall struct {
+	// Method represents the full method name, like /package.Service/Method.
+	Method   string
+	Metadata metadata.MD
+	// Payload represents the encoded request message.
+	Payload []byte
+}
+
+// Unmarshal decodes the request message into m.
+func (c GRPCCall) Unmarshal(m proto.Message) error {
+	return proto.Unmarshal(c.Payload, m)
+}
+
+// GRPCServer is a fake gRPC dependency server listening on an in-memory bufconn listener.
+// It serves any method without registered services, but only the unary calls are supported:
+// a streaming call gets its first message recorded and one scripted response.
+type GRPCServer struct {
+	listener  *bufconn.Listener
+	server    *grpc.Server
+	lock      sync.Mutex
+	responses map[string][]GRPCResponse
+	calls     []GRPCCall
+}
+
+// NewGRPCServer creates and starts a GRPCServer, it should be closed after use.
+func NewGRPCServer() *GRPCServer {
+	s := &GRPCServer{
+		listener:  bufconn.Listen(bufSize),
+		responses: make(map[string][]GRPCResponse),
+	}
+	s.server = grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(s.handle))
+	go func() {
+		_ = s.server.Serve(s.listener)
+	}()
+
+	return s
+}
+
+// On scripts responses for fullMethod, like /package.Service/Method. The responses are served in order,
+// and the last one is served repeatedly.
+func (s *GRPCServer) On(fullMethod string, responses ...GRPCResponse) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[fullMethod] = append(s.responses[fullMethod], responses...)
+}
+
+// Calls returns the received requests in order.
+func (s *GRPCServer) Calls() []GRPCCall {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]GRPCCall(nil), s.calls...)
+}
+
+// Reset removes the scripted responses and the received requests.
+func (s *GRPCServer) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = make(map[string][]GRPCResponse)
+	s.calls = nil
+}
+
+// Dial creates a client connection to the server.
+func (s *GRPCServer) Dial(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+
+	return grpc.DialContext(ctx, "bufnet", opts...)
+}
+
+// Close stops the server and closes the listener.
+func (s *GRPCServer) Close() {
+	s.server.Stop()
+	_ = s.listener.Close()
+}
+
+func (s *GRPCServer) handle(_ any, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	md, _ := metadata.FromIncomingContext(stream.Context())
+
+	var frame rawFrame
+	if err := stream.RecvMsg(&frame); err != nil {
+		return err
+	}
+
+	resp, ok := s.record(GRPCCall{
+		Method:   method,
+		Metadata: md.Copy(),
+		Payload:  frame,
+	})
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "%s: %s", noScriptedResponse, method)
+	}
+	if resp.Status != nil {
+		return resp.Status.Err()
+	}
+	if resp.Reply == nil {
+		return stream.SendMsg(&rawFrame{})
+	}
+
+	return stream.SendMsg(resp.Reply)
+}
+
+func (s *GRPCServer) record(call GRPCCall) (GRPCResponse, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = append(s.calls, call)
+
+	responses := s.responses[call.Method]
+	if len(responses) == 0 {
+		return GRPCResponse{}, false
+	}
+
+	resp := responses[0]
+	if len(responses) > 1 {
+		s.responses[call.Method] = responses[1:]
+	}
+
+	return resp, true
+}
+
+// rawFrame holds an encoded message, the server doesn't know the message types.
+type rawFrame []byte
+
+// rawCodec keeps the received messages encoded and encodes the scripted replies as protobuf.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case *rawFrame:
+		return *m, nil
+	case proto.Message:
+		return proto.Marshal(m)
+	default:
+		return nil, fmt.Errorf("unsupported message type: %T", v)
+	}
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	frame, ok := v.(*rawFrame)
+	if !ok {
+		return fmt.Errorf("unsupported message type: %T", v)
+	}
+
+	*frame = append((*frame)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
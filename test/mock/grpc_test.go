@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const getMethod = "/user.User/Get"
+
+func TestGRPCServer(t *testing.T) {
+	server := NewGRPCServer()
+	defer server.Close()
+
+	notFound, err := status.New(codes.NotFound, "user not found").
+		WithDetails(&errdetails.ErrorInfo{Reason: "USER_NOT_FOUND"})
+	assert.NoError(t, err)
+	server.On(getMethod, GRPCResponse{
+		Reply: wrapperspb.String("anyone"),
+	}, GRPCResponse{
+		Status: notFound,
+	})
+
+	ctx := context.Background()
+	conn, err := server.Dial(ctx)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	var reply wrapperspb.StringValue
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-user", "anyone")
+	err = conn.Invoke(ctx, getMethod, wrapperspb.Int64(1), &reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "anyone", reply.GetValue())
+
+	err = conn.Invoke(ctx, getMethod, wrapperspb.Int64(2), &reply)
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "user not found", st.Message())
+	if assert.Len(t, st.Details(), 1) {
+		assert.Equal(t, "USER_NOT_FOUND", st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	}
+
+	err = conn.Invoke(ctx, "/user.User/Delete", wrapperspb.Int64(1), &reply)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	calls := server.Calls()
+	if assert.Len(t, calls, 3) {
+		var req wrapperspb.Int64Value
+		assert.NoError(t, calls[1].Unmarshal(&req))
+		assert.Equal(t, int64(2), req.GetValue())
+		assert.Equal(t, []string{"anyone"}, calls[0].Metadata.Get("x-user"))
+		assert.Equal(t, "/user.User/Delete", calls[2].Method)
+	}
+
+	server.Reset()
+	assert.Empty(t, server.Calls())
+}
+
+func TestGRPCServerEmptyReply(t *testing.T) {
+	server := NewGRPCServer()
+	defer server.Close()
+	server.On(getMethod, GRPCResponse{})
+
+	conn, err := server.Dial(context.Background(), grpc.WithBlock())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	var reply wrapperspb.StringValue
+	err = conn.Invoke(context.Background(), getMethod, wrapperspb.Int64(1), &reply)
+	assert.NoError(t, err)
+}
+
+func TestRawCodec(t *testing.T) {
+	var codec rawCodec
+	_, err := codec.Marshal(1)
+	assert.Error(t, err)
+	assert.Error(t, codec.Unmarshal(nil, 1))
+}
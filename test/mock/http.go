@@ -0,0 +1,142 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	xhttp "github.com/zeromicro/x/http"
+)
+
+const noScriptedResponse = "no scripted response"
+
+// HTTPResponse is a scripted response of HTTPServer, it's written in the BaseResponse envelope.
+type HTTPResponse struct {
+	// Status represents the http status code, defaults to http.StatusOK.
+	Status int
+	// Header represents the extra headers of the response.
+	Header http.Header
+	// Code represents the business code.
+	Code int
+	// Msg represents the business message, if Code = xhttp.BusinessCodeOK,
+	// and Msg is empty, then the Msg will be set to xhttp.BusinessMsgOk.
+	Msg string
+	// Data represents the business data.
+	Data any
+}
+
+// HTTPCall is a request received by HTTPServer.
+type HTTPCall struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// HTTPServer is a fake HTTP dependency server built on httptest.Server, it listens on a loopback TCP port.
+type HTTPServer struct {
+	*httptest.Server
+	lock      sync.Mutex
+	responses map[string][]HTTPResponse
+	calls     []HTTPCall
+}
+
+// NewHTTPServer creates and starts an HTTPServer, it should be closed after use.
+func NewHTTPServer() *HTTPServer {
+	s := &HTTPServer{
+		responses: make(map[string][]HTTPResponse),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// On scripts responses for the requests with method and path. The responses are served in order,
+// and the last one is served repeatedly.
+func (s *HTTPServer) On(method, path string, responses ...HTTPResponse) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := routeKey(method, path)
+	s.responses[key] = append(s.responses[key], responses...)
+}
+
+// Calls returns the received requests in order.
+func (s *HTTPServer) Calls() []HTTPCall {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]HTTPCall(nil), s.calls...)
+}
+
+// Reset removes the scripted responses and the received requests.
+func (s *HTTPServer) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = make(map[string][]HTTPResponse)
+	s.calls = nil
+}
+
+func (s *HTTPServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, ok := s.record(HTTPCall{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	if !ok {
+		resp = HTTPResponse{
+			Status: http.StatusNotFound,
+			Code:   xhttp.BusinessCodeError,
+			Msg:    noScriptedResponse,
+		}
+	}
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	msg := resp.Msg
+	if resp.Code == xhttp.BusinessCodeOK && len(msg) == 0 {
+		msg = xhttp.BusinessMsgOk
+	}
+	httpx.WriteJsonCtx(r.Context(), w, status, xhttp.BaseResponse[any]{
+		Code: resp.Code,
+		Msg:  msg,
+		Data: resp.Data,
+	})
+}
+
+func (s *HTTPServer) record(call HTTPCall) (HTTPResponse, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = append(s.calls, call)
+
+	key := routeKey(call.Method, call.Path)
+	responses := s.responses[key]
+	if len(responses) == 0 {
+		return HTTPResponse{}, false
+	}
+
+	resp := responses[0]
+	if len(responses) > 1 {
+		s.responses[key] = responses[1:]
+	}
+
+	return resp, true
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
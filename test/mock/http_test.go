@@ -0,0 +1,74 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func TestHTTPServer(t *testing.T) {
+	server := NewHTTPServer()
+	defer server.Close()
+
+	server.On(http.MethodGet, "/users/1", HTTPResponse{
+		Data: user{Name: "anyone"},
+	}, HTTPResponse{
+		Status: http.StatusServiceUnavailable,
+		Header: http.Header{"Retry-After": []string{"1"}},
+		Code:   1001,
+		Msg:    "unavailable",
+	})
+
+	code, header, body := get(t, server.URL+"/users/1?verbose=true")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"code":0,"msg":"ok","data":{"name":"anyone"}}`, body)
+	assert.Empty(t, header.Get("Retry-After"))
+
+	for i := 0; i < 2; i++ {
+		code, header, body = get(t, server.URL+"/users/1")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.JSONEq(t, `{"code":1001,"msg":"unavailable"}`, body)
+		assert.Equal(t, "1", header.Get("Retry-After"))
+	}
+
+	code, _, body = get(t, server.URL+"/users/2")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.JSONEq(t, `{"code":-1,"msg":"no scripted response"}`, body)
+
+	resp, err := http.Post(server.URL+"/users", "application/json", strings.NewReader(`{"name":"anyone"}`))
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	calls := server.Calls()
+	if assert.Len(t, calls, 5) {
+		assert.Equal(t, "verbose=true", calls[0].Query)
+		assert.Equal(t, "/users/2", calls[3].Path)
+		assert.Equal(t, http.MethodPost, calls[4].Method)
+		assert.Equal(t, `{"name":"anyone"}`, string(calls[4].Body))
+	}
+
+	server.Reset()
+	assert.Empty(t, server.Calls())
+}
+
+func get(t *testing.T, url string) (int, http.Header, string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, resp.Header, string(body)
+}
@@ -0,0 +1,315 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+const (
+	defaultRuns       = 100
+	defaultMaxShrinks = 1000
+)
+
+// Generator generates a random input from r, generic type T is the input type.
+type Generator[T any] func(r *rand.Rand) T
+
+// Shrinker returns simpler candidates of v, the simplest candidate comes first,
+// generic type T is the input type.
+type Shrinker[T any] func(v T) []T
+
+// Invariant checks output against input and returns a non-nil error if the property is violated,
+// generic type T is the input type, generic type Y is the output type.
+type Invariant[T, Y any] func(input T, output Y) error
+
+type errorReporter interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// PropertyOption represents an option for Executor.Check, generic type T is the input type.
+type PropertyOption[T any] func(*propertyConfig[T])
+
+type propertyConfig[T any] struct {
+	runs       int
+	seed       int64
+	maxShrinks int
+	shrinker   Shrinker[T]
+}
+
+// WithRuns is an option to set the number of generated inputs, generic type T is the input type.
+func WithRuns[T any](runs int) PropertyOption[T] {
+	return func(c *propertyConfig[T]) {
+		c.runs = runs
+	}
+}
+
+// WithSeed is an option to set the seed of the random source, generic type T is the input type.
+// It's useful to reproduce a failure reported by Executor.Check.
+func WithSeed[T any](seed int64) PropertyOption[T] {
+	return func(c *propertyConfig[T]) {
+		c.seed = seed
+	}
+}
+
+// WithShrinker is an option to set the shrinker which minimizes failed inputs,
+// generic type T is the input type.
+func WithShrinker[T any](shrinker Shrinker[T]) PropertyOption[T] {
+	return func(c *propertyConfig[T]) {
+		c.shrinker = shrinker
+	}
+}
+
+// WithMaxShrinks is an option to set the max number of candidates tried while shrinking,
+// generic type T is the input type.
+func WithMaxShrinks[T any](n int) PropertyOption[T] {
+	return func(c *propertyConfig[T]) {
+		c.maxShrinks = n
+	}
+}
+
+// Check executes a property-based test, generic type T is the input type, generic type Y is the output type.
+// The inputs of the added test cases are checked first, then the inputs built from gen.
+// Every failed input is shrunk to a minimal counterexample if a shrinker is set.
+// The counterexample is added to the Executor as a Data case, which is replayed by the later calls
+// of Check and Fuzz, but not by Run or RunE since it has no wanted output. The failure message
+// prints the case, so that it can be added to the test source with the wanted output to persist it.
+func (e *Executor[T, Y]) Check(t *testing.T, gen Generator[T], do func(T) Y, invariant Invariant[T, Y],
+	opts ...PropertyOption[T]) {
+	e.check(t, gen, do, invariant, opts...)
+}
+
+func (e *Executor[T, Y]) check(t errorReporter, gen Generator[T], do func(T) Y, invariant Invariant[T, Y],
+	opts ...PropertyOption[T]) {
+	t.Helper()
+	if do == nil {
+		panic("execution body is nil")
+	}
+	if gen == nil || invariant == nil {
+		panic("generator or invariant is nil")
+	}
+
+	c := propertyConfig[T]{
+		runs:       defaultRuns,
+		seed:       time.Now().UnixNano(),
+		maxShrinks: defaultMaxShrinks,
+	}
+	for _, o := range opts {
+		o(&c)
+	}
+
+	check := func(input T) error {
+		return checkInvariant(input, do, invariant)
+	}
+
+	for _, v := range e.list {
+		err := check(v.Input)
+		if err == nil {
+			continue
+		}
+		if v.counterexample {
+			t.Errorf("counterexample %q violates the invariant\ninput: %#v\nerror: %v", v.Name, v.Input, err)
+			continue
+		}
+
+		minimal, minimalErr, steps := shrink(v.Input, err, check, c.shrinker, c.maxShrinks)
+		t.Errorf("case %q violates the invariant (shrinks: %d)\ninput: %#v\nerror: %v",
+			v.Name, steps, minimal, minimalErr)
+	}
+
+	r := rand.New(rand.NewSource(c.seed))
+	for i := 0; i < c.runs; i++ {
+		input := gen(r)
+		err := check(input)
+		if err == nil {
+			continue
+		}
+
+		minimal, minimalErr, steps := shrink(input, err, check, c.shrinker, c.maxShrinks)
+		name := fmt.Sprintf("counterexample-%d", c.seed)
+		t.Errorf("property violated after %d runs (seed: %d, shrinks: %d)\ninput: %#v\nerror: %v\n"+
+			"add the case with its wanted output: {Name: %q, Input: %#v}",
+			i+1, c.seed, steps, minimal, minimalErr, name, minimal)
+		e.list = append(e.list, Data[T, Y]{
+			Name:           name,
+			Input:          minimal,
+			counterexample: true,
+		})
+		return
+	}
+}
+
+// Fuzz executes a native fuzz test, generic type T is the input type, generic type Y is the output type.
+// The inputs of the added test cases are used as the seed corpus, so T must be one of the types
+// supported by testing.F. Failed inputs are minimized and saved into testdata/fuzz by the go tool.
+func (e *Executor[T, Y]) Fuzz(f *testing.F, do func(T) Y, invariant Invariant[T, Y]) {
+	if do == nil {
+		panic("execution body is nil")
+	}
+	if invariant == nil {
+		panic("invariant is nil")
+	}
+
+	for _, v := range e.list {
+		f.Add(v.Input)
+	}
+	f.Fuzz(func(t *testing.T, input T) {
+		if err := checkInvariant(input, do, invariant); err != nil {
+			t.Errorf("input: %#v, error: %v", input, err)
+		}
+	})
+}
+
+func checkInvariant[T, Y any](input T, do func(T) Y, invariant Invariant[T, Y]) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return invariant(input, do(input))
+}
+
+func shrink[T any](input T, err error, check func(T) error, shrinker Shrinker[T],
+	maxShrinks int) (T, error, int) {
+	if shrinker == nil {
+		return input, err, 0
+	}
+
+	var steps int
+	for steps < maxShrinks {
+		var shrunk bool
+		for _, candidate := range shrinker(input) {
+			if steps >= maxShrinks {
+				break
+			}
+
+			steps++
+			if cerr := check(candidate); cerr != nil {
+				input, err = candidate, cerr
+				shrunk = true
+				break
+			}
+		}
+		if !shrunk {
+			break
+		}
+	}
+
+	return input, err, steps
+}
+
+// IntRange generates ints in [min, max].
+func IntRange(min, max int) Generator[int] {
+	if max < min {
+		panic("max is less than min")
+	}
+
+	// the span is computed in uint64, since max-min overflows int for the wide ranges
+	span := uint64(max) - uint64(min)
+	return func(r *rand.Rand) int {
+		if span == math.MaxUint64 {
+			return int(r.Uint64())
+		}
+
+		n := span + 1
+		// rejects the values in the last partial block of n, so that the result is uniform
+		threshold := -(-n % n)
+		v := r.Uint64()
+		for threshold != 0 && v >= threshold {
+			v = r.Uint64()
+		}
+		return int(uint64(min) + v%n)
+	}
+}
+
+// StringOf generates strings with at most maxLen runes picked from alphabet.
+func StringOf(alphabet string, maxLen int) Generator[string] {
+	runes := []rune(alphabet)
+	if len(runes) == 0 {
+		panic("empty alphabet")
+	}
+
+	return func(r *rand.Rand) string {
+		n := r.Intn(maxLen + 1)
+		s := make([]rune, n)
+		for i := range s {
+			s[i] = runes[r.Intn(len(runes))]
+		}
+		return string(s)
+	}
+}
+
+// SliceOf generates slices with at most maxLen elements built from gen.
+func SliceOf[E any](gen Generator[E], maxLen int) Generator[[]E] {
+	return func(r *rand.Rand) []E {
+		n := r.Intn(maxLen + 1)
+		s := make([]E, n)
+		for i := range s {
+			s[i] = gen(r)
+		}
+		return s
+	}
+}
+
+// OneOf generates values picked from values.
+func OneOf[E any](values ...E) Generator[E] {
+	if len(values) == 0 {
+		panic("empty values")
+	}
+
+	return func(r *rand.Rand) E {
+		return values[r.Intn(len(values))]
+	}
+}
+
+// ShrinkInt shrinks v towards zero.
+func ShrinkInt(v int) []int {
+	if v == 0 {
+		return nil
+	}
+
+	candidates := []int{0}
+	// -math.MinInt overflows, it's not a candidate
+	if v < 0 && v != math.MinInt {
+		candidates = append(candidates, -v)
+	}
+	for half := v / 2; half != 0 && half != v; half /= 2 {
+		candidates = append(candidates, v-half)
+	}
+
+	return candidates
+}
+
+// ShrinkString shrinks v by removing runes.
+func ShrinkString(v string) []string {
+	runes := []rune(v)
+	shrunk := ShrinkSlice[rune](runes)
+	candidates := make([]string, 0, len(shrunk))
+	for _, s := range shrunk {
+		candidates = append(candidates, string(s))
+	}
+
+	return candidates
+}
+
+// ShrinkSlice shrinks v by removing chunks of elements, the empty slice comes first.
+func ShrinkSlice[E any](v []E) [][]E {
+	if len(v) == 0 {
+		return nil
+	}
+
+	candidates := [][]E{{}}
+	for size := len(v) / 2; size > 0; size /= 2 {
+		for start := 0; start+size <= len(v); start += size {
+			candidate := make([]E, 0, len(v)-size)
+			candidate = append(candidate, v[:start]...)
+			candidate = append(candidate, v[start+size:]...)
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedReporter struct {
+	errors []string
+}
+
+func (r *recordedReporter) Helper() {}
+
+func (r *recordedReporter) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestExecutor_Check(t *testing.T) {
+	executor := NewExecutor[string, string]()
+	executor.Add(Data[string, string]{
+		Name:  "snake_case",
+		Input: "A_B_C",
+		Want:  "a_b_c",
+	})
+	executor.Check(t, StringOf("abcABC_", 10), strings.ToLower, func(input, output string) error {
+		if len(input) != len(output) {
+			return errors.New("length changed")
+		}
+		if strings.ToLower(output) != output {
+			return errors.New("not lower case")
+		}
+		return nil
+	}, WithRuns[string](50), WithSeed[string](1))
+	assert.Len(t, executor.list, 1)
+}
+
+func TestExecutor_CheckShrink(t *testing.T) {
+	executor := NewExecutor[[]int, int]()
+	r := &recordedReporter{}
+	sum := func(s []int) int {
+		var total int
+		for _, v := range s {
+			total += v
+		}
+		return total
+	}
+	executor.check(r, SliceOf(IntRange(0, 100), 20), sum, func(input []int, output int) error {
+		for _, v := range input {
+			if v > 50 {
+				return fmt.Errorf("%d is too large", v)
+			}
+		}
+		return nil
+	}, WithSeed[[]int](1), WithShrinker[[]int](ShrinkSlice[int]))
+
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], `add the case with its wanted output: {Name: "counterexample-1", Input: []int{`)
+	}
+	if assert.Len(t, executor.list, 1) {
+		assert.Equal(t, "counterexample-1", executor.list[0].Name)
+		assert.True(t, executor.list[0].counterexample)
+		assert.Len(t, executor.list[0].Input, 1)
+		assert.Greater(t, executor.list[0].Input[0], 50)
+	}
+
+	// the counterexamples are not run without wanted outputs
+	executor.Run(t, sum)
+
+	// the counterexamples are replayed by the later checks
+	r = &recordedReporter{}
+	executor.check(r, SliceOf(IntRange(0, 50), 20), sum, func(input []int, output int) error {
+		for _, v := range input {
+			if v > 50 {
+				return fmt.Errorf("%d is too large", v)
+			}
+		}
+		return nil
+	}, WithSeed[[]int](1))
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], `counterexample "counterexample-1" violates the invariant`)
+	}
+}
+
+func TestExecutor_CheckShrinkCases(t *testing.T) {
+	executor := NewExecutor[[]int, int]()
+	executor.Add(Data[[]int, int]{Name: "large", Input: []int{1, 2, 60, 3}, Want: 66})
+	r := &recordedReporter{}
+	executor.check(r, SliceOf(IntRange(0, 10), 5), func(s []int) int {
+		return len(s)
+	}, func(input []int, output int) error {
+		for _, v := range input {
+			if v > 50 {
+				return fmt.Errorf("%d is too large", v)
+			}
+		}
+		return nil
+	}, WithSeed[[]int](1), WithShrinker[[]int](ShrinkSlice[int]))
+
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], `case "large"`)
+		assert.Contains(t, r.errors[0], "input: []int{60}")
+	}
+	assert.Len(t, executor.list, 1)
+}
+
+func TestExecutor_CheckPanic(t *testing.T) {
+	executor := NewExecutor[int, int]()
+	executor.Add(Data[int, int]{Name: "zero"})
+	r := &recordedReporter{}
+	executor.check(r, IntRange(0, 10), func(i int) int {
+		return 10 / i
+	}, func(input, output int) error {
+		return nil
+	}, WithRuns[int](0))
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], "panic")
+	}
+}
+
+func TestShrinkInt(t *testing.T) {
+	assert.Empty(t, ShrinkInt(0))
+	assert.Equal(t, []int{0, 5, 8, 9}, ShrinkInt(10))
+	assert.Equal(t, []int{0, 10, -5, -8, -9}, ShrinkInt(-10))
+	for _, v := range ShrinkInt(math.MinInt) {
+		assert.LessOrEqual(t, v, 0)
+	}
+}
+
+func TestShrinkString(t *testing.T) {
+	assert.Empty(t, ShrinkString(""))
+	assert.Equal(t, []string{"", "cd", "ab", "bcd", "acd", "abd", "abc"}, ShrinkString("abcd"))
+}
+
+func TestGenerators(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		v := IntRange(-3, 3)(r)
+		assert.True(t, v >= -3 && v <= 3)
+		assert.Contains(t, []string{"a", "b"}, OneOf("a", "b")(r))
+		assert.LessOrEqual(t, len(SliceOf(IntRange(0, 1), 5)(r)), 5)
+	}
+	for _, gen := range []Generator[int]{
+		IntRange(0, math.MaxInt),
+		IntRange(math.MinInt, 0),
+		IntRange(math.MinInt, math.MaxInt),
+		IntRange(math.MaxInt, math.MaxInt),
+	} {
+		assert.NotPanics(t, func() {
+			gen(r)
+		})
+	}
+	for i := 0; i < 100; i++ {
+		assert.GreaterOrEqual(t, IntRange(0, math.MaxInt)(r), 0)
+		assert.LessOrEqual(t, IntRange(math.MinInt, 0)(r), 0)
+	}
+	assert.Equal(t, math.MaxInt, IntRange(math.MaxInt, math.MaxInt)(r))
+	assert.Panics(t, func() {
+		IntRange(1, 0)
+	})
+	assert.Panics(t, func() {
+		StringOf("", 1)
+	})
+}
+
+func FuzzExecutor(f *testing.F) {
+	executor := NewExecutor[string, string]()
+	executor.Add([]Data[string, string]{
+		{
+			Name:  "snake_case",
+			Input: "A_B_C",
+		},
+		{
+			Name:  "camel_case",
+			Input: "AaBbCc",
+		},
+	}...)
+	executor.Fuzz(f, strings.ToUpper, func(input, output string) error {
+		if strings.ToUpper(output) != output {
+			return errors.New("not upper case")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/x/provider"
+)
+
+var (
+	_ provider.Clock       = (*FakeClock)(nil)
+	_ provider.IDGenerator = (*SequenceIDGenerator)(nil)
+)
+
+// FakeClock is a controllable provider.Clock, it's frozen unless a tick is set.
+type FakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+	tick time.Duration
+}
+
+// NewFakeClock creates a FakeClock frozen at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current fake time, then advances the clock by the tick.
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.tick)
+	return now
+}
+
+// Advance advances the clock by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the clock to now.
+func (c *FakeClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
+
+// Tick makes the clock advance by d on every call of Now.
+func (c *FakeClock) Tick(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.tick = d
+}
+
+// Freeze stops the clock from advancing on calls of Now.
+func (c *FakeClock) Freeze() {
+	c.Tick(0)
+}
+
+// SequenceIDGenerator is a provider.IDGenerator that generates sequential IDs,
+// like prefix-1, prefix-2 and so on.
+type SequenceIDGenerator struct {
+	lock   sync.Mutex
+	prefix string
+	next   int
+}
+
+// NewSequenceIDGenerator creates a SequenceIDGenerator with prefix.
+func NewSequenceIDGenerator(prefix string) *SequenceIDGenerator {
+	return &SequenceIDGenerator{
+		prefix: prefix,
+		next:   1,
+	}
+}
+
+// NewID returns the next ID.
+func (g *SequenceIDGenerator) NewID() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	id := fmt.Sprintf("%s-%d", g.prefix, g.next)
+	g.next++
+	return id
+}
+
+// Reset restarts the sequence from 1.
+func (g *SequenceIDGenerator) Reset() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.next = 1
+}
+
+// NewSeededRand creates a provider.Rand which generates the same sequence for the same seed.
+func NewSeededRand(seed int64) provider.Rand {
+	return provider.NewRand(seed)
+}
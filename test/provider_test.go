@@ -0,0 +1,39 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	assert.Equal(t, start, clock.Now())
+	assert.Equal(t, start, clock.Now())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), clock.Now())
+
+	clock.Tick(time.Minute)
+	assert.Equal(t, start.Add(time.Second), clock.Now())
+	assert.Equal(t, start.Add(time.Second+time.Minute), clock.Now())
+
+	clock.Freeze()
+	clock.Set(start)
+	assert.Equal(t, start, clock.Now())
+	assert.Equal(t, start, clock.Now())
+}
+
+func TestSequenceIDGenerator(t *testing.T) {
+	g := NewSequenceIDGenerator("req")
+	assert.Equal(t, "req-1", g.NewID())
+	assert.Equal(t, "req-2", g.NewID())
+	g.Reset()
+	assert.Equal(t, "req-1", g.NewID())
+}
+
+func TestNewSeededRand(t *testing.T) {
+	assert.Equal(t, NewSeededRand(1).Int63(), NewSeededRand(1).Int63())
+}
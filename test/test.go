@@ -1,7 +1,6 @@
 package test
 
 import (
-	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,6 +12,9 @@ type Data[T, Y any] struct {
 	Input T
 	Want  Y
 	E     error
+	// counterexample represents whether the case is a counterexample found by Executor.Check,
+	// it has no wanted output, so it's only replayed by Check and Fuzz, not by Run or RunE.
+	counterexample bool
 }
 
 // Option represents an option for Executor, generic type T is the input type, generic type Y is the want type.
@@ -36,17 +38,7 @@ type Executor[T, Y any] struct {
 // NewExecutor creates an Executor, generic type T is the input type, generic type Y is the want type.
 func NewExecutor[T, Y any](opt ...Option[T, Y]) *Executor[T, Y] {
 	e := &Executor[T, Y]{}
-	options := []Option[T, Y]{WithComparison[T, Y](func(t *testing.T, expected, actual Y) {
-		gotBytes, err := json.Marshal(actual)
-		if err != nil {
-			t.Fatal(err)
-		}
-		wantBytes, err := json.Marshal(expected)
-		if err != nil {
-			t.Fatal(err)
-		}
-		assert.JSONEq(t, string(wantBytes), string(gotBytes))
-	})}
+	options := []Option[T, Y]{WithMatchers[T, Y]()}
 
 	options = append(options, opt...)
 
@@ -65,9 +57,11 @@ func (e *Executor[T, Y]) Add(data ...Data[T, Y]) {
 func (e *Executor[T, Y]) Run(t *testing.T, do func(T) Y) {
 	if do == nil {
 		panic("execution body is nil")
-		return
 	}
 	for _, v := range e.list {
+		if v.counterexample {
+			continue
+		}
 		t.Run(v.Name, func(t *testing.T) {
 			inner := do
 			e.equalFn(t, v.Want, inner(v.Input))
@@ -79,9 +73,11 @@ func (e *Executor[T, Y]) Run(t *testing.T, do func(T) Y) {
 func (e *Executor[T, Y]) RunE(t *testing.T, do func(T) (Y, error)) {
 	if do == nil {
 		panic("execution body is nil")
-		return
 	}
 	for _, v := range e.list {
+		if v.counterexample {
+			continue
+		}
 		t.Run(v.Name, func(t *testing.T) {
 			inner := do
 			got, err := inner(v.Input)
@@ -0,0 +1,139 @@
+package validation
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zeromicro/x/internal/negotiate"
+)
+
+const (
+	// LanguageEnglish represents the English messages, which are the fallback.
+	LanguageEnglish = "en"
+	// LanguageChinese represents the simplified Chinese messages.
+	LanguageChinese = "zh"
+
+	// summaryKey is the key of the message summarizing the errors, used as the msg of the CodeMsg.
+	summaryKey = "summary"
+)
+
+// Messages maps the rule names to the message templates, {field} and {param} in the templates
+// are replaced with the field name and the rule parameter. The summary key is the message of the CodeMsg.
+type Messages map[string]string
+
+var (
+	messages = map[string]Messages{
+		LanguageEnglish: {
+			summaryKey:   "invalid params",
+			ruleRequired: "{field} is required",
+			ruleMin:      "{field} must be at least {param}",
+			ruleMax:      "{field} must be at most {param}",
+			ruleLen:      "{field} must have a length of {param}",
+			ruleRegex:    "{field} has an invalid format",
+			ruleOneOf:    "{field} must be one of [{param}]",
+			ruleEmail:    "{field} must be a valid email address",
+			ruleURL:      "{field} must be a valid URL",
+			ruleEqField:  "{field} must be equal to {param}",
+			ruleNeField:  "{field} must not be equal to {param}",
+			ruleGtField:  "{field} must be greater than {param}",
+			ruleGteField: "{field} must be greater than or equal to {param}",
+			ruleLtField:  "{field} must be less than {param}",
+			ruleLteField: "{field} must be less than or equal to {param}",
+			ruleUnknown:  "{field} is invalid",
+		},
+		LanguageChinese: {
+			summaryKey:   "参数错误",
+			ruleRequired: "{field}不能为空",
+			ruleMin:      "{field}不能小于{param}",
+			ruleMax:      "{field}不能大于{param}",
+			ruleLen:      "{field}的长度必须为{param}",
+			ruleRegex:    "{field}格式不正确",
+			ruleOneOf:    "{field}必须是[{param}]中的一个",
+			ruleEmail:    "{field}必须是有效的邮箱地址",
+			ruleURL:      "{field}必须是有效的URL",
+			ruleEqField:  "{field}必须等于{param}",
+			ruleNeField:  "{field}不能等于{param}",
+			ruleGtField:  "{field}必须大于{param}",
+			ruleGteField: "{field}必须大于或等于{param}",
+			ruleLtField:  "{field}必须小于{param}",
+			ruleLteField: "{field}必须小于或等于{param}",
+			ruleUnknown:  "{field}无效",
+		},
+	}
+	messagesLock sync.RWMutex
+)
+
+// RegisterMessages registers the message templates of lang, they're merged into the existing ones of lang.
+// The missing templates fall back to English.
+func RegisterMessages(lang string, msgs Messages) {
+	messagesLock.Lock()
+	defer messagesLock.Unlock()
+
+	lang = normalizeLanguage(lang)
+	existing, ok := messages[lang]
+	if !ok {
+		existing = make(Messages, len(msgs))
+		messages[lang] = existing
+	}
+	for k, v := range msgs {
+		existing[k] = v
+	}
+}
+
+// translate renders the message of key in lang, falls back to English.
+func translate(lang, key, field, param string) string {
+	messagesLock.RLock()
+	tmpl, ok := messages[lang][key]
+	if !ok {
+		if tmpl, ok = messages[LanguageEnglish][key]; !ok {
+			tmpl = messages[LanguageEnglish][ruleUnknown]
+		}
+	}
+	messagesLock.RUnlock()
+
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(tmpl)
+}
+
+// negotiateLanguage returns the language with the highest quality in the Accept-Language header
+// with registered messages, or fallback if none. The earlier language wins on a tie.
+func negotiateLanguage(header, fallback string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q, ok := negotiate.ParseQuality(params)
+		if !ok || q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{lang: normalizeLanguage(tag), q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	messagesLock.RLock()
+	defer messagesLock.RUnlock()
+
+	for _, c := range candidates {
+		if _, ok := messages[c.lang]; ok {
+			return c.lang
+		}
+		// zh-CN falls back to zh
+		if base, _, ok := strings.Cut(c.lang, "-"); ok {
+			if _, ok := messages[base]; ok {
+				return base
+			}
+		}
+	}
+
+	return fallback
+}
+
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.TrimSpace(lang))
+}
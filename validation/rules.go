@@ -0,0 +1,297 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ruleRequired = "required"
+	ruleMin      = "min"
+	ruleMax      = "max"
+	ruleLen      = "len"
+	ruleRegex    = "regex"
+	ruleOneOf    = "oneof"
+	ruleEnum     = "enum"
+	ruleEmail    = "email"
+	ruleURL      = "url"
+	ruleEqField  = "eqfield"
+	ruleNeField  = "nefield"
+	ruleGtField  = "gtfield"
+	ruleGteField = "gtefield"
+	ruleLtField  = "ltfield"
+	ruleLteField = "ltefield"
+	ruleDive     = "dive"
+	ruleUnknown  = "unknown"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	nameTags = []string{"json", "form", "path", "header"}
+)
+
+type (
+	// rule is a parsed tag rule.
+	rule struct {
+		name  string
+		param string
+		// check returns whether v passes the rule, parent is the struct containing v.
+		check func(v, parent reflect.Value) bool
+		// field is the index of the compared field of the cross-field rules.
+		field []int
+		// fieldName is the display name of the compared field of the cross-field rules.
+		fieldName string
+	}
+
+	// fieldRules is the parsed rules of a struct field.
+	fieldRules struct {
+		index    []int
+		name     string
+		required bool
+		rules    []rule
+		// dive represents the rules applied to the elements of a slice, an array or a map.
+		dive    *fieldRules
+		hasDive bool
+	}
+)
+
+// parseRules parses the tag of a field in t, the rules after dive apply to the elements.
+func parseRules(t reflect.Type, tag string) (*fieldRules, error) {
+	fr := &fieldRules{}
+	parts := strings.Split(tag, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		switch name {
+		case ruleRequired:
+			fr.required = true
+		case ruleDive:
+			elem, err := parseRules(t, strings.Join(parts[i+1:], ","))
+			if err != nil {
+				return nil, err
+			}
+			fr.dive = elem
+			fr.hasDive = true
+			return fr, nil
+		default:
+			r, err := newRule(t, name, param)
+			if err != nil {
+				return nil, err
+			}
+			fr.rules = append(fr.rules, r)
+		}
+	}
+
+	return fr, nil
+}
+
+func newRule(t reflect.Type, name, param string) (rule, error) {
+	r := rule{name: name, param: param}
+	switch name {
+	case ruleMin, ruleMax, ruleLen:
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return r, fmt.Errorf("invalid %s parameter %q", name, param)
+		}
+		r.check = func(v, _ reflect.Value) bool {
+			size, ok := sizeOf(v)
+			if !ok {
+				return false
+			}
+			switch name {
+			case ruleMin:
+				return size >= limit
+			case ruleMax:
+				return size <= limit
+			default:
+				return size == limit
+			}
+		}
+	case ruleRegex:
+		re, err := regexp.Compile(param)
+		if err != nil {
+			return r, fmt.Errorf("invalid regex %q: %w", param, err)
+		}
+		r.check = func(v, _ reflect.Value) bool {
+			return v.Kind() == reflect.String && re.MatchString(v.String())
+		}
+	case ruleOneOf, ruleEnum:
+		r.name = ruleOneOf
+		options := strings.Fields(param)
+		r.param = strings.Join(options, " ")
+		r.check = func(v, _ reflect.Value) bool {
+			s := formatValue(v)
+			for _, option := range options {
+				if s == option {
+					return true
+				}
+			}
+			return false
+		}
+	case ruleEmail:
+		r.check = func(v, _ reflect.Value) bool {
+			if v.Kind() != reflect.String {
+				return false
+			}
+			addr, err := mail.ParseAddress(v.String())
+			return err == nil && addr.Address == v.String()
+		}
+	case ruleURL:
+		r.check = func(v, _ reflect.Value) bool {
+			if v.Kind() != reflect.String {
+				return false
+			}
+			u, err := url.Parse(v.String())
+			return err == nil && len(u.Scheme) > 0 && len(u.Host) > 0
+		}
+	case ruleEqField, ruleNeField, ruleGtField, ruleGteField, ruleLtField, ruleLteField:
+		if t == nil || t.Kind() != reflect.Struct {
+			return r, fmt.Errorf("%s is only allowed on struct fields", name)
+		}
+		field, ok := t.FieldByName(param)
+		if !ok {
+			return r, fmt.Errorf("unknown field %q of %s", param, name)
+		}
+		r.field = field.Index
+		r.fieldName = fieldName(field)
+		r.check = func(v, parent reflect.Value) bool {
+			other := parent.FieldByIndex(r.field)
+			cmp, ok := compare(v, other)
+			if !ok {
+				return false
+			}
+			switch name {
+			case ruleEqField:
+				return cmp == 0
+			case ruleNeField:
+				return cmp != 0
+			case ruleGtField:
+				return cmp > 0
+			case ruleGteField:
+				return cmp >= 0
+			case ruleLtField:
+				return cmp < 0
+			default:
+				return cmp <= 0
+			}
+		}
+	default:
+		return r, fmt.Errorf("unknown rule %q", name)
+	}
+
+	return r, nil
+}
+
+// sizeOf returns the number of a number, or the length of a string, a slice, an array or a map.
+func sizeOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	default:
+		return 0, false
+	}
+}
+
+// compare compares a and b of the same kind, it returns false if they're not comparable.
+func compare(a, b reflect.Value) (int, bool) {
+	a, b = indirect(a), indirect(b)
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false
+	}
+
+	if a.Type() == timeType && b.Type() == timeType {
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+
+	fa, aok := number(a)
+	fb, bok := number(b)
+	if !aok || !bok {
+		return 0, false
+	}
+
+	switch {
+	case fa < fb:
+		return -1, true
+	case fa > fb:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return 0, false
+	default:
+		return sizeOf(v)
+	}
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// fieldName returns the name of field in the go-zero request tags, like json and form,
+// or the go name if it has none.
+func fieldName(field reflect.StructField) string {
+	for _, key := range nameTags {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
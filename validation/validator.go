@@ -0,0 +1,286 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/zeromicro/x/errors"
+)
+
+const (
+	defaultTagName        = "validate"
+	acceptLanguageHeader  = "Accept-Language"
+	defaultMaxFieldErrors = 32
+)
+
+type (
+	// FieldError is the validation error of a field, it's the item of the CodeMsg details.
+	FieldError struct {
+		// Field represents the path of the field, like items[0].name.
+		Field string `json:"field" xml:"field"`
+		// Rule represents the failed rule, like required.
+		Rule string `json:"rule" xml:"rule"`
+		// Param represents the parameter of the failed rule, like 10 of max=10.
+		Param string `json:"param,omitempty" xml:"param,omitempty"`
+		// Msg represents the localized message.
+		Msg string `json:"msg" xml:"msg"`
+	}
+
+	// Option represents an option for New.
+	Option func(*Validator)
+
+	// Validator validates the structs with the rules in the struct tags, it implements httpx.Validator,
+	// so it can be installed by httpx.SetValidator(validation.New()). The rules are separated by commas:
+	//
+	//	required        the value must not be zero, nil pointers, empty strings, slices and maps are zero
+	//	min=1, max=10   the number must be in range, or the length of a string, a slice or a map
+	//	len=6           the length must be equal
+	//	regex=^\d+$     the string must match, commas in the pattern must be written as \x2c
+	//	oneof=a b c     the value must be one of the options separated by spaces, enum is an alias
+	//	email, url      the string must be a valid email address or an absolute URL
+	//	eqfield=F       the value must be equal to the field F in the same struct,
+	//	                nefield, gtfield, gtefield, ltfield and ltefield compare likewise
+	//	dive            the rules after dive apply to the elements of a slice, an array or a map
+	//
+	// The zero values without required are not validated by the other rules, except the numbers,
+	// so min=1 rejects 0, the optional numbers can be declared as pointers.
+	// The nested structs are validated recursively, so are the struct elements after dive.
+	Validator struct {
+		tagName   string
+		lang      string
+		code      int
+		maxErrors int
+		cache     sync.Map
+	}
+
+	structRules struct {
+		fields []*fieldRules
+		err    error
+	}
+
+	walker struct {
+		v      *Validator
+		lang   string
+		errors []FieldError
+	}
+)
+
+// WithTagName is an option to set the struct tag name of the rules, defaults to validate.
+func WithTagName(name string) Option {
+	return func(v *Validator) {
+		v.tagName = name
+	}
+}
+
+// WithLanguage is an option to set the default language of the messages, defaults to English.
+// The language of the requests is negotiated from the Accept-Language header.
+func WithLanguage(lang string) Option {
+	return func(v *Validator) {
+		v.lang = normalizeLanguage(lang)
+	}
+}
+
+// WithCode is an option to set the business code of the CodeMsg,
+// defaults to the code of errors.KindInvalidParams.
+func WithCode(code int) Option {
+	return func(v *Validator) {
+		v.code = code
+	}
+}
+
+// WithMaxFieldErrors is an option to set the maximum number of the reported field errors, defaults to 32.
+func WithMaxFieldErrors(n int) Option {
+	return func(v *Validator) {
+		v.maxErrors = n
+	}
+}
+
+// New creates a Validator.
+func New(opts ...Option) *Validator {
+	v := &Validator{
+		tagName:   defaultTagName,
+		lang:      LanguageEnglish,
+		maxErrors: defaultMaxFieldErrors,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// Validate validates data parsed from r, the messages are localized in the language of r.
+// It implements httpx.Validator.
+func (v *Validator) Validate(r *http.Request, data any) error {
+	return v.ValidateLang(negotiateLanguage(r.Header.Get(acceptLanguageHeader), v.lang), data)
+}
+
+// Struct validates data with the default language.
+func (v *Validator) Struct(data any) error {
+	return v.ValidateLang(v.lang, data)
+}
+
+// ValidateLang validates data with the messages in lang. It returns an errors.CodeMsg with
+// the []FieldError details if data is invalid, so it can be written by the base response writers,
+// or a plain error if the rules are malformed.
+func (v *Validator) ValidateLang(lang string, data any) error {
+	w := walker{v: v, lang: lang}
+	if err := w.walk("", reflect.ValueOf(data)); err != nil {
+		return err
+	}
+	if len(w.errors) == 0 {
+		return nil
+	}
+
+	msg := translate(lang, summaryKey, "", "")
+	if v.code != 0 {
+		return errors.New(v.code, msg, errors.WithCategory(errors.CategoryClient), errors.WithDetails(w.errors))
+	}
+
+	return errors.InvalidParams(msg, errors.WithDetails(w.errors))
+}
+
+func (v *Validator) rulesOf(t reflect.Type) *structRules {
+	if cached, ok := v.cache.Load(t); ok {
+		return cached.(*structRules)
+	}
+
+	sr := &structRules{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fr, err := parseRules(t, field.Tag.Get(v.tagName))
+		if err != nil {
+			sr.err = fmt.Errorf("validation: invalid rules of %s.%s: %w", t.Name(), field.Name, err)
+			break
+		}
+		fr.index = field.Index
+		fr.name = fieldName(field)
+		sr.fields = append(sr.fields, fr)
+	}
+
+	cached, _ := v.cache.LoadOrStore(t, sr)
+	return cached.(*structRules)
+}
+
+// walk validates the fields of the struct val recursively, path is the path of val.
+func (w *walker) walk(path string, val reflect.Value) error {
+	val = indirect(val)
+	if !val.IsValid() || val.Kind() != reflect.Struct || val.Type() == timeType {
+		return nil
+	}
+
+	sr := w.v.rulesOf(val.Type())
+	if sr.err != nil {
+		return sr.err
+	}
+
+	for _, fr := range sr.fields {
+		if err := w.check(joinPath(path, fr.name), val.FieldByIndex(fr.index), val, fr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) check(path string, val, parent reflect.Value, fr *fieldRules) error {
+	if isZero(val) {
+		if fr.required {
+			w.report(path, ruleRequired, "")
+			return nil
+		}
+		// 0 is a valid number, so the other rules still apply
+		if !isNumber(val) {
+			return nil
+		}
+	}
+
+	for _, r := range fr.rules {
+		if !r.check(indirect(val), parent) {
+			param := r.param
+			if len(r.fieldName) > 0 {
+				param = r.fieldName
+			}
+			w.report(path, r.name, param)
+			// the other rules of the field are skipped to avoid the noisy messages
+			return nil
+		}
+	}
+
+	elem := indirect(val)
+	if fr.hasDive {
+		switch elem.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < elem.Len(); i++ {
+				if err := w.check(path+"["+strconv.Itoa(i)+"]", elem.Index(i), parent, fr.dive); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			iter := elem.MapRange()
+			for iter.Next() {
+				key := fmt.Sprint(iter.Key().Interface())
+				if err := w.check(path+"["+key+"]", iter.Value(), parent, fr.dive); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return w.walk(path, val)
+}
+
+func (w *walker) report(path, rule, param string) {
+	if w.v.maxErrors > 0 && len(w.errors) >= w.v.maxErrors {
+		return
+	}
+
+	w.errors = append(w.errors, FieldError{
+		Field: path,
+		Rule:  rule,
+		Param: param,
+		Msg:   translate(w.lang, rule, path, param),
+	})
+}
+
+func isZero(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func joinPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+
+	return path + "." + name
+}
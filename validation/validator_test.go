@@ -0,0 +1,288 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/x/errors"
+	xhttp "github.com/zeromicro/x/http"
+	"github.com/zeromicro/x/test"
+)
+
+type (
+	address struct {
+		City string `json:"city" validate:"required"`
+		Zip  string `json:"zip,optional" validate:"regex=^\\d{6}$"`
+	}
+
+	item struct {
+		Name  string `json:"name" validate:"required,max=8"`
+		Count int    `json:"count" validate:"min=1"`
+	}
+
+	signup struct {
+		Name     string            `json:"name" validate:"required,min=2,max=10"`
+		Email    string            `json:"email" validate:"required,email"`
+		Homepage string            `json:"homepage,optional" validate:"url"`
+		Role     string            `json:"role,optional" validate:"oneof=admin user"`
+		Password string            `json:"password" validate:"required,len=6"`
+		Confirm  string            `json:"confirm" validate:"eqfield=Password"`
+		Address  *address          `json:"address,optional"`
+		Items    []item            `json:"items,optional" validate:"max=3,dive"`
+		Tags     []string          `json:"tags,optional" validate:"dive,required,max=3"`
+		Labels   map[string]string `json:"labels,optional" validate:"dive,oneof=a b"`
+		Start    time.Time         `json:"start,optional"`
+		End      time.Time         `json:"end,optional" validate:"gtfield=Start"`
+	}
+)
+
+func validSignup() signup {
+	return signup{
+		Name:     "anyone",
+		Email:    "anyone@example.com",
+		Password: "secret",
+		Confirm:  "secret",
+	}
+}
+
+func TestValidatorStruct(t *testing.T) {
+	now := time.Now()
+	executor := test.NewExecutor[signup, []FieldError]()
+	executor.Add([]test.Data[signup, []FieldError]{
+		{
+			Name:  "valid",
+			Input: validSignup(),
+		},
+		{
+			Name: "required",
+			Input: func() signup {
+				s := validSignup()
+				s.Name = ""
+				return s
+			}(),
+			Want: []FieldError{{Field: "name", Rule: "required", Msg: "name is required"}},
+		},
+		{
+			Name: "min and email",
+			Input: func() signup {
+				s := validSignup()
+				s.Name = "a"
+				s.Email = "anyone"
+				return s
+			}(),
+			Want: []FieldError{
+				{Field: "name", Rule: "min", Param: "2", Msg: "name must be at least 2"},
+				{Field: "email", Rule: "email", Msg: "email must be a valid email address"},
+			},
+		},
+		{
+			Name: "url and oneof",
+			Input: func() signup {
+				s := validSignup()
+				s.Homepage = "example.com"
+				s.Role = "root"
+				return s
+			}(),
+			Want: []FieldError{
+				{Field: "homepage", Rule: "url", Msg: "homepage must be a valid URL"},
+				{Field: "role", Rule: "oneof", Param: "admin user", Msg: "role must be one of [admin user]"},
+			},
+		},
+		{
+			Name: "len and eqfield",
+			Input: func() signup {
+				s := validSignup()
+				s.Password = "secret1"
+				return s
+			}(),
+			Want: []FieldError{
+				{Field: "password", Rule: "len", Param: "6", Msg: "password must have a length of 6"},
+				{Field: "confirm", Rule: "eqfield", Param: "password", Msg: "confirm must be equal to password"},
+			},
+		},
+		{
+			Name: "nested",
+			Input: func() signup {
+				s := validSignup()
+				s.Address = &address{Zip: "123"}
+				return s
+			}(),
+			Want: []FieldError{
+				{Field: "address.city", Rule: "required", Msg: "address.city is required"},
+				{Field: "address.zip", Rule: "regex", Param: `^\d{6}$`, Msg: "address.zip has an invalid format"},
+			},
+		},
+		{
+			Name: "dive",
+			Input: func() signup {
+				s := validSignup()
+				s.Items = []item{{Name: "book", Count: 1}, {Name: "notebooks", Count: 0}}
+				s.Tags = []string{"go", "", "rust"}
+				s.Labels = map[string]string{"k": "c"}
+				return s
+			}(),
+			Want: []FieldError{
+				{Field: "items[1].name", Rule: "max", Param: "8", Msg: "items[1].name must be at most 8"},
+				{Field: "items[1].count", Rule: "min", Param: "1", Msg: "items[1].count must be at least 1"},
+				{Field: "tags[1]", Rule: "required", Msg: "tags[1] is required"},
+				{Field: "tags[2]", Rule: "max", Param: "3", Msg: "tags[2] must be at most 3"},
+				{Field: "labels[k]", Rule: "oneof", Param: "a b", Msg: "labels[k] must be one of [a b]"},
+			},
+		},
+		{
+			Name: "gtfield",
+			Input: func() signup {
+				s := validSignup()
+				s.Start = now
+				s.End = now.Add(-time.Hour)
+				return s
+			}(),
+			Want: []FieldError{{Field: "end", Rule: "gtfield", Param: "start", Msg: "end must be greater than start"}},
+		},
+	}...)
+
+	v := New()
+	executor.Run(t, func(s signup) []FieldError {
+		err := v.Struct(s)
+		if err == nil {
+			return nil
+		}
+
+		cm, ok := err.(*errors.CodeMsg)
+		assert.True(t, ok)
+		assert.Equal(t, 40000, cm.Code)
+		assert.Equal(t, "invalid params", cm.Msg)
+		return cm.Details().([]FieldError)
+	})
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	executor := test.NewExecutor[string, string]()
+	executor.Add([]test.Data[string, string]{
+		{Name: "empty", Input: "", Want: "en"},
+		{Name: "first", Input: "zh-CN,en", Want: "zh"},
+		{Name: "quality", Input: "en;q=0.5, zh;q=0.9", Want: "zh"},
+		{Name: "tie", Input: "en;q=0.8, zh;q=0.8", Want: "en"},
+		{Name: "params", Input: "zh;level=1;q=0.1, en;q=0.2", Want: "en"},
+		{Name: "unacceptable", Input: "zh;q=0, ja", Want: "en"},
+		{Name: "malformed", Input: "zh;q=abc, en;q=0.1", Want: "en"},
+	}...)
+	executor.Run(t, func(header string) string {
+		return negotiateLanguage(header, LanguageEnglish)
+	})
+}
+
+func TestValidatorLanguage(t *testing.T) {
+	v := New()
+	r := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+	r.Header.Set(acceptLanguageHeader, "zh-CN,zh;q=0.9,en;q=0.8")
+	err := v.Validate(r, &signup{Email: "anyone@example.com", Password: "secret"})
+	cm, ok := err.(*errors.CodeMsg)
+	assert.True(t, ok)
+	assert.Equal(t, "参数错误", cm.Msg)
+	assert.Equal(t, []FieldError{{Field: "name", Rule: "required", Msg: "name不能为空"}}, cm.Details())
+
+	RegisterMessages("fr", Messages{ruleRequired: "{field} est obligatoire"})
+	r.Header.Set(acceptLanguageHeader, "fr-FR")
+	err = v.Validate(r, &signup{Email: "anyone@example.com", Password: "secret"})
+	cm, ok = err.(*errors.CodeMsg)
+	assert.True(t, ok)
+	assert.Equal(t, "invalid params", cm.Msg)
+	assert.Equal(t, "name est obligatoire", cm.Details().([]FieldError)[0].Msg)
+
+	v = New(WithLanguage(LanguageChinese))
+	err = v.Struct(signup{Email: "anyone@example.com", Password: "secret"})
+	assert.Equal(t, "参数错误", err.(*errors.CodeMsg).Msg)
+}
+
+func TestValidatorOptions(t *testing.T) {
+	type request struct {
+		A string `check:"required"`
+		B string `check:"required"`
+	}
+
+	v := New(WithTagName("check"), WithCode(4220), WithMaxFieldErrors(1))
+	err := v.Struct(request{})
+	cm, ok := err.(*errors.CodeMsg)
+	assert.True(t, ok)
+	assert.Equal(t, 4220, cm.Code)
+	assert.Equal(t, []FieldError{{Field: "A", Rule: "required", Msg: "A is required"}}, cm.Details())
+
+	assert.NoError(t, v.Struct(nil))
+	assert.NoError(t, v.Struct("not a struct"))
+}
+
+func TestValidatorInvalidRules(t *testing.T) {
+	type (
+		unknownRule struct {
+			A string `validate:"unknown"`
+		}
+		badParam struct {
+			A string `validate:"max=ten"`
+		}
+		badRegex struct {
+			A string `validate:"regex=("`
+		}
+		unknownField struct {
+			A string `validate:"eqfield=B"`
+		}
+	)
+
+	v := New()
+	for _, data := range []any{unknownRule{A: "a"}, badParam{A: "a"}, badRegex{A: "a"}, unknownField{A: "a"}} {
+		err := v.Struct(data)
+		assert.Error(t, err)
+		_, ok := err.(*errors.CodeMsg)
+		assert.False(t, ok)
+	}
+}
+
+// httpxValidator delegates to the wrapped validator if any, it's installed into httpx both on setting up
+// and restoring, because httpx keeps the validator in an atomic.Value, which can't be reset to nil
+// or be stored with another type.
+type httpxValidator struct {
+	httpx.Validator
+}
+
+func (v httpxValidator) Validate(r *http.Request, data any) error {
+	if v.Validator == nil {
+		return nil
+	}
+
+	return v.Validator.Validate(r, data)
+}
+
+func TestValidatorWithHttpx(t *testing.T) {
+	httpx.SetValidator(httpxValidator{New()})
+	defer httpx.SetValidator(httpxValidator{})
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req signup
+		if err := httpx.Parse(r, &req); err != nil {
+			xhttp.JsonBaseResponseCtx(r.Context(), w, err)
+			return
+		}
+		xhttp.JsonBaseResponseCtx(r.Context(), w, req.Name)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+		`{"name":"anyone","email":"anyone","password":"secret","confirm":"secret"}`))
+	r.Header.Set(httpx.ContentType, httpx.JsonContentType)
+	handler(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"code":40000,"msg":"invalid params","data":[
+		{"field":"email","rule":"email","msg":"email must be a valid email address"}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+		`{"name":"anyone","email":"anyone@example.com","password":"secret","confirm":"secret"}`))
+	r.Header.Set(httpx.ContentType, httpx.JsonContentType)
+	handler(w, r)
+	assert.JSONEq(t, `{"code":0,"msg":"ok","data":"anyone"}`, w.Body.String())
+}